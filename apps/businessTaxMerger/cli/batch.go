@@ -0,0 +1,354 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"accountingTools/apps/businessTaxMerger/core"
+)
+
+// 批次模式的結束代碼
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// stringList 可重複指定的字串參數（例如 -input a -input b，或 -input a,b）
+type stringList []string
+
+// String 參數值
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set 加入參數值
+func (l *stringList) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// batchOptions 批次模式參數
+type batchOptions struct {
+	inputs         stringList
+	output         string
+	maxRows        int
+	desiredCount   int
+	strategy       string
+	groupBy        string
+	splitOversized bool
+	format         string
+	csvEncoding    string
+	profile        string
+	provenance     bool
+	renumber       bool
+	dropDuplicates bool
+	taxTolerance   int64
+	sequenceCheck  bool
+	workers        int
+	yes            bool
+	dryRun         bool
+}
+
+// 批次模式參數值對應
+var (
+	batchStrategies = map[string]core.AllocationStrategy{
+		"next-fit": core.StrategyNextFit,
+		"ffd":      core.StrategyFirstFitDecreasing,
+		"balanced": core.StrategyBalanced,
+	}
+	batchGroupBy = map[string]core.GroupBy{
+		"none":      core.GroupByNone,
+		"declarant": core.GroupByDeclarant,
+		"period":    core.GroupByPeriod,
+		"format":    core.GroupByFormatCode,
+	}
+	batchFormats   = []string{outputModeExcel, outputModeWorkbook, outputModeTxt, outputModeCSV, outputModeJSONL}
+	batchEncodings = map[string]core.FileEncoding{
+		"utf8-bom": core.EncodingUTF8BOM,
+		"utf8":     core.EncodingUTF8,
+		"big5":     core.EncodingBig5,
+	}
+)
+
+// optionNames 參數可用值（排序後以 | 連接，用於說明文字）
+func optionNames[T any](options map[string]T) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// CommandName 說明文字中的指令名稱（整合入口執行時為「accountingTools merge」等）
+var CommandName = "businessTaxMerger"
+
+// newFlagSet 建立參數解析器，說明文字第一行為用法
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "用法: %s %s\n", CommandName, usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// collectTxtFiles 收集各資料夾中的 TXT 檔案
+func collectTxtFiles(folders []string) ([]string, error) {
+	txtFiles := make([]string, 0)
+	for _, folder := range folders {
+		if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("輸入資料夾不存在: %s", folder)
+		}
+		files, err := filepath.Glob(filepath.Join(folder, "*.txt"))
+		if err != nil {
+			return nil, err
+		}
+		txtFiles = append(txtFiles, files...)
+	}
+	if len(txtFiles) == 0 {
+		return nil, errors.New("輸入資料夾中沒有找到 TXT 檔案")
+	}
+	fmt.Printf("找到 %d 個 TXT 檔案\n", len(txtFiles))
+	return txtFiles, nil
+}
+
+// parseBatchOptions 解析批次模式參數
+func parseBatchOptions(args []string) (*batchOptions, error) {
+	opts := &batchOptions{}
+	flags := newFlagSet("merge", "-input <資料夾> [參數]（不加參數時進入互動模式）")
+	flags.Var(&opts.inputs, "input", "TXT 所在資料夾，可重複指定或以逗號分隔（必填）")
+	flags.StringVar(&opts.output, "output", "", "輸出資料夾（預設為第一個輸入資料夾）")
+	flags.IntVar(&opts.maxRows, "max-rows", core.MaxExcelDataRows, "每個 Excel 的最大資料筆數（不含標題列，僅 Excel 輸出）")
+	flags.IntVar(&opts.desiredCount, "count", 10, "期望產出的 Excel 檔案個數（僅 Excel 輸出）")
+	flags.StringVar(&opts.strategy, "strategy", "next-fit", "分配策略（僅 Excel 輸出）："+optionNames(batchStrategies))
+	flags.StringVar(&opts.groupBy, "group-by", "none", "分組方式："+optionNames(batchGroupBy))
+	flags.BoolVar(&opts.splitOversized, "split", false, "將超過最大列數的檔案拆分到連續的 Excel")
+	flags.StringVar(&opts.format, "format", outputModeExcel, "輸出格式："+strings.Join(batchFormats, "|"))
+	flags.StringVar(&opts.csvEncoding, "csv-encoding", "utf8-bom", "CSV 編碼："+optionNames(batchEncodings))
+	flags.StringVar(&opts.profile, "profile", "", "欄位設定：內建設定名稱或 JSON/YAML 設定檔路徑")
+	flags.BoolVar(&opts.provenance, "provenance", true, "在欄位設定後附加來源檔案與行號欄位（-provenance=false 時完全依欄位設定）")
+	flags.BoolVar(&opts.renumber, "renumber", false, "合併為申報 TXT 時依申報營業人重新編列流水號")
+	flags.BoolVar(&opts.sequenceCheck, "sequence-check", false, "另產出銷項發票字軌檢查 Excel（缺號、重複、非本期）")
+	flags.BoolVar(&opts.dropDuplicates, "drop-duplicates", false, "刪除跨檔完全重複的發票資料（每組保留第一筆）")
+	flags.Int64Var(&opts.taxTolerance, "tax-tolerance", core.DefaultTaxTolerance, "營業稅額與銷售金額 × 稅率容許的差額（元，每張發票）")
+	flags.IntVar(&opts.workers, "workers", core.DefaultWorkers(), "並行處理的 worker 數")
+	flags.BoolVar(&opts.yes, "yes", false, "不詢問確認，直接匯出")
+	flags.BoolVar(&opts.dryRun, "dry-run", false, "只分析並顯示分配結果，不產出檔案")
+
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() > 0 {
+		return nil, fmt.Errorf("無法辨識的參數: %s", strings.Join(flags.Args(), " "))
+	}
+	if len(opts.inputs) == 0 {
+		return nil, errors.New("請以 -input 指定 TXT 所在資料夾")
+	}
+	if opts.maxRows <= 0 || opts.desiredCount <= 0 {
+		return nil, errors.New("-max-rows 與 -count 必須為正整數")
+	}
+	if opts.taxTolerance < 0 {
+		return nil, errors.New("-tax-tolerance 不可為負數")
+	}
+	if _, ok := batchStrategies[opts.strategy]; !ok {
+		return nil, fmt.Errorf("未知的分配策略 %q（可用：%s）", opts.strategy, optionNames(batchStrategies))
+	}
+	if _, ok := batchGroupBy[opts.groupBy]; !ok {
+		return nil, fmt.Errorf("未知的分組方式 %q（可用：%s）", opts.groupBy, optionNames(batchGroupBy))
+	}
+	if _, ok := batchEncodings[opts.csvEncoding]; !ok {
+		return nil, fmt.Errorf("未知的 CSV 編碼 %q（可用：%s）", opts.csvEncoding, optionNames(batchEncodings))
+	}
+	validFormat := false
+	for _, format := range batchFormats {
+		validFormat = validFormat || opts.format == format
+	}
+	if !validFormat {
+		return nil, fmt.Errorf("未知的輸出格式 %q（可用：%s）", opts.format, strings.Join(batchFormats, "|"))
+	}
+	if excelOutput(opts.format) && opts.maxRows > core.MaxExcelDataRows {
+		return nil, fmt.Errorf("-max-rows 不可超過 %d（Excel 工作表列數上限扣除標題列）", core.MaxExcelDataRows)
+	}
+	if opts.output == "" {
+		opts.output = opts.inputs[0]
+	}
+	return opts, nil
+}
+
+// loadProfile 依名稱取得內建欄位設定，查無時視為設定檔路徑讀取；空白為預設欄位設定
+func loadProfile(nameOrPath string) (*core.ColumnProfile, error) {
+	if nameOrPath == "" {
+		return nil, nil
+	}
+	for _, profile := range core.ColumnProfiles {
+		if profile.Name == nameOrPath {
+			return profile, nil
+		}
+	}
+	return core.LoadColumnProfile(nameOrPath)
+}
+
+// excelOutput 輸出格式是否為 Excel（受工作表列數上限限制，須依 -max-rows、-count 分配檔案）
+func excelOutput(outputMode string) bool {
+	return outputMode == outputModeExcel || outputMode == outputModeWorkbook
+}
+
+// usesPeriods 輸出格式是否寫入整期計算結果（403 試算、字軌檢查工作表）
+func usesPeriods(outputMode string) bool {
+	return excelOutput(outputMode)
+}
+
+// batchExporter 依參數建立匯出器，periods 為 Excel 輸出使用的整期計算結果
+func batchExporter(opts *batchOptions, profile *core.ColumnProfile, periods *core.PeriodReport) core.Exporter {
+	validation := core.ValidationOptions{TaxTolerance: opts.taxTolerance}
+	switch opts.format {
+	case outputModeTxt:
+		return &core.TxtExporter{Renumber: opts.renumber, Validation: validation}
+	case outputModeWorkbook:
+		return &core.WorkbookExporter{Profile: profile, MaxRows: opts.maxRows, Validation: validation, OmitProvenance: !opts.provenance, Periods: periods}
+	case outputModeCSV:
+		return &core.CSVExporter{Profile: profile, Encoding: batchEncodings[opts.csvEncoding], Workers: opts.workers, Validation: validation, OmitProvenance: !opts.provenance}
+	case outputModeJSONL:
+		return &core.JSONLinesExporter{Profile: profile, Workers: opts.workers, Validation: validation, OmitProvenance: !opts.provenance}
+	default:
+		return &core.ExcelExporter{Profile: profile, MaxRows: opts.maxRows, Workers: opts.workers, Validation: validation, OmitProvenance: !opts.provenance, Periods: periods}
+	}
+}
+
+// Merge 以命令列參數執行合併（不進入互動選單），回傳結束代碼
+func Merge(args []string) int {
+	opts, err := parseBatchOptions(args)
+	if err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		fmt.Fprintf(os.Stderr, "參數錯誤: %v\n", err)
+		return exitUsage
+	}
+
+	if err := batchMerge(opts); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// batchMerge 分析、分配並匯出；任一步驟失敗時回傳錯誤
+func batchMerge(opts *batchOptions) error {
+	profile, err := loadProfile(opts.profile)
+	if err != nil {
+		return err
+	}
+	txtFiles, err := collectTxtFiles(opts.inputs)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(opts.output); err != nil || !info.IsDir() {
+		return fmt.Errorf("輸出資料夾不存在: %s", opts.output)
+	}
+
+	// 分析、分組（可按 Ctrl+C 中止）
+	ctx, stop := interruptContext()
+	defer stop()
+
+	fileInfoList, err := core.AnalyzeFiles(ctx, txtFiles, opts.workers)
+	if err != nil {
+		return fmt.Errorf("分析檔案時發生錯誤: %v", err)
+	}
+	if len(fileInfoList) == 0 {
+		return errors.New("沒有成功分析到任何檔案")
+	}
+	if groupBy := batchGroupBy[opts.groupBy]; groupBy != core.GroupByNone {
+		groupedList, err := core.GroupFiles(ctx, fileInfoList, groupBy, opts.workers)
+		core.ReleaseFiles(fileInfoList)
+		if err != nil {
+			return fmt.Errorf("分組時發生錯誤: %v", err)
+		}
+		fileInfoList = groupedList
+	}
+	defer core.ReleaseFiles(fileInfoList)
+
+	// 重複發票與彙總登錄範圍共用同一次讀取；刪除完全重複的資料後再比對彙總登錄範圍
+	checks, err := core.CheckFiles(ctx, fileInfoList)
+	if err != nil {
+		return fmt.Errorf("重複發票與彙總登錄範圍檢查時發生錯誤: %v", err)
+	}
+	displayDuplicateReport(checks.Duplicates)
+	if opts.dropDuplicates && checks.Duplicates.CopyCount() > 0 {
+		dropped, err := core.DropExactDuplicates(ctx, checks.Duplicates)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✓ 已刪除 %d 筆完全重複的資料\n", dropped)
+	}
+	displaySummaryRangeIssues(checks.SummaryRangeIssues())
+
+	// 非 Excel 輸出沒有工作表列數上限，所有檔案依序匯出到單一檔案，不套用 -max-rows、-count 與分配策略
+	allocation := [][]*core.TxtFileInfo{fileInfoList}
+	if excelOutput(opts.format) {
+		allocation, err = core.ValidateAndAllocateFiles(ctx, fileInfoList, opts.maxRows, opts.desiredCount, batchStrategies[opts.strategy], opts.splitOversized)
+		if err != nil {
+			return fmt.Errorf("驗證失敗：%v", err)
+		}
+		core.DisplayAllocation(allocation, opts.maxRows)
+	} else {
+		totalRecords := 0
+		for _, fileInfo := range fileInfoList {
+			totalRecords += fileInfo.RecordCount
+		}
+		fmt.Printf("共 %d 個檔案、%d 筆資料將匯出到單一檔案\n", len(fileInfoList), totalRecords)
+	}
+
+	if opts.dryRun {
+		fmt.Println("（dry-run：未產出任何檔案）")
+		return nil
+	}
+	if !opts.yes {
+		fmt.Print("是否確認以上分配並開始匯出？(y/n): ")
+		if !confirmYes() {
+			return errors.New("已取消匯出")
+		}
+	}
+
+	// 403 試算與字軌檢查以整期資料計算，Excel 匯出與字軌檢查共用同一次讀取
+	var periods *core.PeriodReport
+	if usesPeriods(opts.format) || opts.sequenceCheck {
+		if periods, err = core.AnalyzePeriods(ctx, allocation); err != nil {
+			if ctx.Err() != nil {
+				return errors.New("已中止匯出")
+			}
+			return fmt.Errorf("整期試算時發生錯誤: %v", err)
+		}
+	}
+
+	exporter := batchExporter(opts, profile, periods)
+	fmt.Printf("開始匯出 %s...\n", exporter.Name())
+	report, err := exporter.Export(ctx, allocation, opts.output)
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.New("已中止匯出，未完成的檔案已刪除（已完成的檔案保留）")
+		}
+		return fmt.Errorf("%s 匯出失敗：%v", exporter.Name(), err)
+	}
+
+	fmt.Printf("✓ %s 檔案產出成功！輸出位置: %s\n", exporter.Name(), opts.output)
+	displayValidationReport(report)
+
+	if opts.sequenceCheck {
+		if err := exportInvoiceSequence(periods.Sequence, opts.output); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"accountingTools/apps/businessTaxMerger/core"
+)
+
+// parseArgs 解析參數，回傳是否繼續執行與結束代碼（-h 時不繼續並回傳 exitOK）
+func parseArgs(flags *flag.FlagSet, args []string) (bool, int) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, exitOK
+		}
+		return false, exitUsage
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "參數錯誤: 無法辨識的參數: %s\n", strings.Join(flags.Args(), " "))
+		return false, exitUsage
+	}
+	return true, exitOK
+}
+
+// Validate 分析 TXT 並逐筆驗證（不匯出），有結構錯誤或驗證問題時回傳 exitFailure
+func Validate(args []string) int {
+	var inputs stringList
+	flags := newFlagSet("validate", "-input <資料夾> [參數]")
+	flags.Var(&inputs, "input", "TXT 所在資料夾，可重複指定或以逗號分隔（必填）")
+	taxTolerance := flags.Int64("tax-tolerance", core.DefaultTaxTolerance, "營業稅額與銷售金額 × 稅率容許的差額（元，每張發票）")
+	workers := flags.Int("workers", core.DefaultWorkers(), "並行處理的 worker 數")
+	if ok, code := parseArgs(flags, args); !ok {
+		return code
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintln(os.Stderr, "參數錯誤: 請以 -input 指定 TXT 所在資料夾")
+		return exitUsage
+	}
+	if *taxTolerance < 0 {
+		fmt.Fprintln(os.Stderr, "參數錯誤: -tax-tolerance 不可為負數")
+		return exitUsage
+	}
+
+	txtFiles, err := collectTxtFiles(inputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	fileInfoList, err := core.AnalyzeFiles(ctx, txtFiles, *workers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 分析檔案時發生錯誤: %v\n", err)
+		return exitFailure
+	}
+	defer core.ReleaseFiles(fileInfoList)
+
+	report, err := core.ValidateFiles(ctx, fileInfoList, core.ValidationOptions{TaxTolerance: *taxTolerance})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+	if report.HasParseErrors() || report.HasIssues() {
+		displayValidationReport(report)
+		return exitFailure
+	}
+	fmt.Println("✓ 所有資料皆通過驗證")
+	return exitOK
+}
+
+// Convert 將 Excel 轉回媒體申報 TXT，有儲存格無法轉換時回傳 exitFailure
+func Convert(args []string) int {
+	flags := newFlagSet("convert", "-input <Excel 檔案> [-output <TXT 檔案>]")
+	input := flags.String("input", "", "要轉換的 Excel 檔案（必填）")
+	output := flags.String("output", "", "輸出的 TXT 檔案（預設為 Excel 檔名加上「_轉出.txt」）")
+	if ok, code := parseArgs(flags, args); !ok {
+		return code
+	}
+	if *input == "" {
+		fmt.Fprintln(os.Stderr, "參數錯誤: 請以 -input 指定 Excel 檔案")
+		return exitUsage
+	}
+	if _, err := os.Stat(*input); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 檔案不存在或無法存取: %s\n", *input)
+		return exitFailure
+	}
+
+	outputPath := *output
+	if outputPath == "" {
+		outputPath = strings.TrimSuffix(*input, filepath.Ext(*input)) + "_轉出.txt"
+	}
+
+	convertErrors, err := core.ImportExcelToTxt(*input, outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 轉換失敗：%v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("   輸出位置: %s\n", outputPath)
+	if len(convertErrors) > 0 {
+		fmt.Printf("⚠ %d 個儲存格無法轉換，所在資料列未寫入：\n", len(convertErrors))
+		for _, convertError := range convertErrors {
+			fmt.Printf("  - %v\n", convertError)
+		}
+		return exitFailure
+	}
+	return exitOK
+}
+
+// Report 計算 401 申報書試算並匯出 Excel 與 JSON
+// 同一申報營業人的各期依序計算，上期的累積留抵稅額自動帶入下一期
+func Report(args []string) int {
+	var inputs, credits stringList
+	flags := newFlagSet("report", "-input <資料夾> [參數]")
+	flags.Var(&inputs, "input", "TXT 所在資料夾，可重複指定或以逗號分隔（必填）")
+	output := flags.String("output", "", "輸出資料夾（預設為第一個輸入資料夾）")
+	flags.Var(&credits, "previous-credit", "第一期的上期累積留抵稅額，格式為 稅籍編號=金額，可重複指定")
+	workers := flags.Int("workers", core.DefaultWorkers(), "並行處理的 worker 數")
+	if ok, code := parseArgs(flags, args); !ok {
+		return code
+	}
+	if len(inputs) == 0 {
+		fmt.Fprintln(os.Stderr, "參數錯誤: 請以 -input 指定 TXT 所在資料夾")
+		return exitUsage
+	}
+	previousCredits := make(map[string]int64, len(credits))
+	for _, credit := range credits {
+		taxId, amount, found := strings.Cut(credit, "=")
+		value, err := strconv.ParseInt(strings.TrimSpace(amount), 10, 64)
+		if !found || err != nil || value < 0 {
+			fmt.Fprintf(os.Stderr, "參數錯誤: -previous-credit 格式應為 稅籍編號=金額: %s\n", credit)
+			return exitUsage
+		}
+		previousCredits[strings.TrimSpace(taxId)] = value
+	}
+	if *output == "" {
+		*output = inputs[0]
+	}
+
+	txtFiles, err := collectTxtFiles(inputs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	fileInfoList, err := core.AnalyzeFiles(ctx, txtFiles, *workers)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 分析檔案時發生錯誤: %v\n", err)
+		return exitFailure
+	}
+	defer core.ReleaseFiles(fileInfoList)
+
+	returns, err := core.ComputeReturn401(ctx, [][]*core.TxtFileInfo{fileInfoList})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ 401 申報書試算失敗：%v\n", err)
+		return exitFailure
+	}
+
+	// 申報書依申報營業人、期別排序，同一營業人的下一期帶入本期累積留抵稅額
+	for i, r := range returns {
+		if i > 0 && returns[i-1].DeclarantTaxId == r.DeclarantTaxId {
+			r.SetPreviousCredit(returns[i-1].CarriedForwardCredit)
+		} else if credit, ok := previousCredits[r.DeclarantTaxId]; ok {
+			r.SetPreviousCredit(credit)
+		}
+	}
+
+	xlsxPath, jsonPath, err := core.ExportReturn401(returns, *output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return exitFailure
+	}
+	fmt.Printf("✓ 已產出 401 申報書試算: %s\n", xlsxPath)
+	fmt.Printf("✓ 已產出 401 申報書資料: %s\n", jsonPath)
+	return exitOK
+}
@@ -0,0 +1,818 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"accountingTools/apps/businessTaxMerger/core"
+)
+
+// Interactive 互動模式：以選單逐步選擇資料夾、參數與輸出格式，可連續處理多個資料夾
+func Interactive() {
+	continueProgram := true
+
+	for continueProgram {
+		// clearScreen()
+		fmt.Print("\033[H\033[2J")
+		// printHeader()
+		fmt.Println("╔══════════════════════════════════════════════════════════╗")
+		fmt.Println("║            營業人進銷項資料檔合併工具 v2.0 (Go)          ║")
+		fmt.Println("║                                                          ║")
+		fmt.Println("║    流程：選擇資料夾 → 分析 TXT → 合併 → 匯出 Excel       ║")
+		fmt.Println("╚══════════════════════════════════════════════════════════╝")
+		fmt.Println()
+
+		// 選擇功能：Excel 轉回 TXT 為獨立流程
+		if selectFunction() == functionExcelToTxt {
+			runExcelToTxt()
+
+			fmt.Println()
+			fmt.Print("====== 是否要繼續使用？(y/n): ")
+			if !confirmYes() {
+				continueProgram = false
+			}
+			continue
+		}
+
+		// Step 1: 選擇資料夾
+		folderPath, txtFiles, err := selectFolderAndFiles()
+		if err != nil {
+			fmt.Printf("錯誤: %v\n", err)
+			fmt.Println("按 Enter 重新選擇資料夾...")
+			bufio.NewReader(os.Stdin).ReadBytes('\n')
+			continue
+		}
+
+		if len(txtFiles) == 0 {
+			fmt.Println("資料夾中沒有找到 TXT 檔案。")
+			fmt.Println("按 Enter 重新選擇資料夾...")
+			bufio.NewReader(os.Stdin).ReadBytes('\n')
+			continue
+		}
+
+		fmt.Printf("\n找到 %d 個 TXT 檔案：\n", len(txtFiles))
+		displayLimit := 10
+		if len(txtFiles) < displayLimit {
+			displayLimit = len(txtFiles)
+		}
+		for i := 0; i < displayLimit; i++ {
+			fmt.Printf("  %d. %s\n", i+1, filepath.Base(txtFiles[i]))
+		}
+		if len(txtFiles) > 10 {
+			fmt.Printf("  ... 以及其他 %d 個檔案\n", len(txtFiles)-10)
+		}
+
+		fmt.Print("\n是否開始處理這些檔案？(y/n): ")
+		if !confirmYes() {
+			continue
+		}
+
+		// Step 2: 分析 TXT 檔案（可按 Ctrl+C 中止）
+		fmt.Println()
+		ctx, stop := interruptContext()
+		fileInfoList, err := core.AnalyzeFiles(ctx, txtFiles, core.DefaultWorkers())
+		stop()
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n已中止分析")
+			} else {
+				fmt.Printf("分析檔案時發生錯誤: %v\n", err)
+			}
+			continue
+		}
+
+		if len(fileInfoList) == 0 {
+			fmt.Println("沒有成功分析到任何檔案！")
+			fmt.Print("是否要重新嘗試？(y/n): ")
+			if !confirmYes() {
+				continueProgram = false
+			}
+			continue
+		}
+
+		// Step 3: 取得使用者參數
+		maxRowsPerExcel, desiredExcelCount := getUserParameters()
+
+		// 選擇分組依據：每組資料各自產出 Excel
+		groupBy := selectGroupBy()
+		if groupBy != core.GroupByNone {
+			fmt.Println()
+			ctx, stop := interruptContext()
+			groupedList, err := core.GroupFiles(ctx, fileInfoList, groupBy, core.DefaultWorkers())
+			stop()
+			core.ReleaseFiles(fileInfoList)
+			if err != nil {
+				if ctx.Err() != nil {
+					fmt.Println("\n已中止分組")
+				} else {
+					fmt.Printf("分組時發生錯誤: %v\n", err)
+				}
+				continue
+			}
+			fileInfoList = groupedList
+		}
+
+		// 跨檔比對重複發票並展開彙總登錄的號碼範圍（共用同一次讀取，問題列入匯出的驗證報告）
+		// 有完全重複的資料時詢問是否刪除（須在分配前處理），刪除的資料不再列入彙總登錄範圍的比對
+		fmt.Println()
+		ctx, stop = interruptContext()
+		checks, err := core.CheckFiles(ctx, fileInfoList)
+		if err == nil {
+			displayDuplicateReport(checks.Duplicates)
+			if copies := checks.Duplicates.CopyCount(); copies > 0 {
+				fmt.Printf("是否刪除 %d 筆完全重複的資料（每組保留第一筆）？(y/n): ", copies)
+				if confirmYes() {
+					var dropped int
+					dropped, err = core.DropExactDuplicates(ctx, checks.Duplicates)
+					if err == nil {
+						fmt.Printf("✓ 已刪除 %d 筆完全重複的資料\n", dropped)
+					}
+				}
+			}
+		}
+		stop()
+		if err != nil {
+			if ctx.Err() != nil {
+				fmt.Println("\n已中止重複發票與彙總登錄範圍檢查")
+			} else {
+				fmt.Printf("重複發票與彙總登錄範圍檢查時發生錯誤: %v\n", err)
+			}
+			core.ReleaseFiles(fileInfoList)
+			continue
+		}
+		displaySummaryRangeIssues(checks.SummaryRangeIssues())
+
+		// 有檔案超過最大列數時，詢問是否拆分到連續的 Excel
+		splitOversized := false
+		for _, fileInfo := range fileInfoList {
+			if fileInfo.RecordCount > maxRowsPerExcel {
+				fmt.Println()
+				fmt.Printf("檔案 '%s' 有 %d 筆資料，超過每個 Excel 的最大列數 %d\n", fileInfo.FileName, fileInfo.RecordCount, maxRowsPerExcel)
+				fmt.Print("是否將超過最大列數的檔案拆分到連續的 Excel？(y/n): ")
+				splitOversized = confirmYes()
+				break
+			}
+		}
+
+		// Step 4: 比較分配策略後選擇，驗證並分配檔案
+		core.DisplayStrategyComparison(fileInfoList, maxRowsPerExcel, desiredExcelCount, splitOversized)
+		strategy := selectAllocationStrategy()
+
+		fmt.Println()
+		fmt.Println("正在驗證檔案分配...")
+
+		allocation, err := core.ValidateAndAllocateFiles(context.Background(), fileInfoList, maxRowsPerExcel, desiredExcelCount, strategy, splitOversized)
+		if err != nil {
+			fmt.Println()
+			fmt.Printf("❌ 驗證失敗：%v\n", err)
+			core.ReleaseFiles(fileInfoList)
+			fmt.Print("\n是否要重新設定參數？(y/n): ")
+			if !confirmYes() {
+				continueProgram = false
+			}
+			continue
+		}
+
+		// 顯示分配結果
+		core.DisplayAllocation(allocation, maxRowsPerExcel)
+
+		// 選擇輸出格式
+		outputMode := selectOutputMode()
+		var profile *core.ColumnProfile
+		provenance := true
+		if outputMode != outputModeTxt {
+			profile = selectColumnProfile()
+			fmt.Print("是否省略來源檔案與行號欄位，完全依欄位設定？（預設附加）(y/n): ")
+			provenance = !confirmYes()
+		}
+		validation := core.ValidationOptions{TaxTolerance: selectTaxTolerance()}
+
+		// 確認是否繼續
+		fmt.Println()
+		if outputMode == outputModeTxt {
+			fmt.Print("是否確認以上分配並開始合併為申報 TXT？(y/n): ")
+		} else {
+			fmt.Print("是否確認以上分配並開始匯出？(y/n): ")
+		}
+		if !confirmYes() {
+			core.ReleaseFiles(fileInfoList)
+			continue
+		}
+
+		// 403 試算與字軌檢查以整期資料計算，Excel 匯出與之後的 401 試算、字軌檢查共用同一次讀取
+		var periods *core.PeriodReport
+		if usesPeriods(outputMode) {
+			if periods, err = ensurePeriods(nil, allocation); err != nil {
+				fmt.Printf("❌ %v\n", err)
+				core.ReleaseFiles(fileInfoList)
+				continue
+			}
+		}
+
+		// Step 5: 依輸出格式匯出
+		exporter := newExporter(outputMode, profile, provenance, maxRowsPerExcel, validation, periods)
+
+		fmt.Println()
+		fmt.Println("═══════════════════════════════════════════════════")
+		fmt.Printf("開始匯出 %s...（可按 Ctrl+C 中止）\n", exporter.Name())
+		fmt.Println("═══════════════════════════════════════════════════")
+		fmt.Println()
+
+		ctx, stop = interruptContext()
+		report, err := exporter.Export(ctx, allocation, folderPath)
+		stop()
+		if err != nil {
+			fmt.Println()
+			if ctx.Err() != nil {
+				fmt.Println("❌ 已中止匯出，未完成的檔案已刪除（已完成的檔案保留）")
+			} else {
+				fmt.Printf("❌ %s 匯出失敗：%v\n", exporter.Name(), err)
+			}
+		} else {
+			fmt.Println()
+			fmt.Printf("✓ %s 檔案產出成功！\n", exporter.Name())
+			fmt.Printf("   輸出位置: %s\n", folderPath)
+			displayValidationReport(report)
+		}
+
+		// 401 申報書試算
+		fmt.Println()
+		fmt.Print("是否產出 401 申報書試算？(y/n): ")
+		if confirmYes() {
+			if periods, err = ensurePeriods(periods, allocation); err != nil {
+				fmt.Printf("❌ %v\n", err)
+			} else {
+				exportReturn401(periods.Returns401(), folderPath)
+			}
+		}
+
+		// 銷項發票字軌檢查
+		fmt.Println()
+		fmt.Print("是否產出銷項發票字軌檢查（缺號、重複、非本期）？(y/n): ")
+		if confirmYes() {
+			if periods, err = ensurePeriods(periods, allocation); err == nil {
+				err = exportInvoiceSequence(periods.Sequence, folderPath)
+			}
+			if err != nil {
+				fmt.Printf("❌ %v\n", err)
+			}
+		}
+
+		// 刪除分析時產生的暫存檔
+		core.ReleaseFiles(fileInfoList)
+
+		// 詢問是否繼續
+		fmt.Println()
+		fmt.Println()
+		fmt.Print("====== 是否要繼續處理其他資料夾？(y/n): ")
+		if !confirmYes() {
+			continueProgram = false
+		}
+	}
+
+	fmt.Println("\n感謝使用，再見！")
+	fmt.Println("按 Enter 離開...")
+	bufio.NewReader(os.Stdin).ReadBytes('\n')
+}
+
+// interruptContext 建立按下 Ctrl+C 時取消的 context
+// 長時間處理期間使用，結束後需呼叫 stop 恢復 Ctrl+C 直接結束程式的預設行為
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// ensurePeriods 尚未計算時讀取分配結果計算整期資料（403 試算、401 申報書與字軌檢查），已計算時直接回傳
+func ensurePeriods(periods *core.PeriodReport, allocation [][]*core.TxtFileInfo) (*core.PeriodReport, error) {
+	if periods != nil {
+		return periods, nil
+	}
+	fmt.Println("正在計算申報書試算與字軌檢查...（可按 Ctrl+C 中止）")
+	ctx, stop := interruptContext()
+	defer stop()
+	periods, err := core.AnalyzePeriods(ctx, allocation)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, errors.New("已中止申報書試算與字軌檢查")
+		}
+		return nil, fmt.Errorf("申報書試算與字軌檢查失敗：%v", err)
+	}
+	return periods, nil
+}
+
+// exportReturn401 匯出 401 申報書試算，逐一詢問各期的上期累積留抵稅額
+func exportReturn401(returns []*core.Return401, folderPath string) {
+	reader := bufio.NewReader(os.Stdin)
+	for _, r := range returns {
+		for {
+			fmt.Printf("請輸入 %s %s 的上期累積留抵稅額 (預設: 0): ", r.DeclarantTaxId, r.Period())
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(input)
+			if input == "" {
+				break
+			}
+			val, err := strconv.ParseInt(input, 10, 64)
+			if err != nil || val < 0 {
+				fmt.Println("請輸入有效的非負整數")
+				continue
+			}
+			r.SetPreviousCredit(val)
+			break
+		}
+	}
+
+	xlsxPath, jsonPath, err := core.ExportReturn401(returns, folderPath)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		return
+	}
+	fmt.Printf("✓ 已產出 401 申報書試算: %s\n", filepath.Base(xlsxPath))
+	fmt.Printf("✓ 已產出 401 申報書資料: %s\n", filepath.Base(jsonPath))
+	for _, r := range returns {
+		if r.PayableTax > 0 {
+			fmt.Printf("   %s %s：本期應實繳稅額 %d\n", r.DeclarantTaxId, r.Period(), r.PayableTax)
+		} else {
+			fmt.Printf("   %s %s：本期申報留抵稅額 %d（應退稅額 %d）\n", r.DeclarantTaxId, r.Period(), r.CreditTax, r.RefundTax)
+		}
+	}
+}
+
+// exportInvoiceSequence 匯出銷項發票字軌檢查 Excel，顯示檢查結果摘要
+func exportInvoiceSequence(report *core.SequenceReport, folderPath string) error {
+	xlsxPath, err := core.ExportInvoiceSequence(report, folderPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✓ 已產出發票字軌檢查: %s\n", filepath.Base(xlsxPath))
+	if count := report.FindingCount(); count > 0 {
+		fmt.Printf("⚠ %d 個字軌共有 %d 項缺號、重複、非本期或未用完的號碼，請參閱「字軌檢查」工作表\n", len(report.Tracks), count)
+	} else {
+		fmt.Printf("   %d 個字軌的號碼皆連續\n", len(report.Tracks))
+	}
+	return nil
+}
+
+// displayValidationReport 顯示驗證報告摘要（最多列出前 10 筆）
+func displayValidationReport(report *core.ValidationReport) {
+	if report == nil {
+		return
+	}
+
+	if report.HasParseErrors() {
+		fmt.Println()
+		fmt.Printf("⚠ %d 個資料行結構錯誤未匯入：\n", len(report.ParseErrors))
+		displayLimit := 10
+		if len(report.ParseErrors) < displayLimit {
+			displayLimit = len(report.ParseErrors)
+		}
+		for i := 0; i < displayLimit; i++ {
+			fmt.Printf("  - %v\n", report.ParseErrors[i])
+		}
+		if len(report.ParseErrors) > 10 {
+			fmt.Printf("  ... 以及其他 %d 個錯誤\n", len(report.ParseErrors)-10)
+		}
+	}
+
+	if !report.HasIssues() {
+		return
+	}
+
+	fmt.Println()
+	fmt.Printf("⚠ 驗證發現 %d 個問題（Excel 中以紅底標示，重複發票整列以黃底標示）：\n", len(report.Issues))
+	displayLimit := 10
+	if len(report.Issues) < displayLimit {
+		displayLimit = len(report.Issues)
+	}
+	for i := 0; i < displayLimit; i++ {
+		fmt.Printf("  - %s\n", report.Issues[i])
+	}
+	if len(report.Issues) > 10 {
+		fmt.Printf("  ... 以及其他 %d 個問題\n", len(report.Issues)-10)
+	}
+}
+
+// displayDuplicateReport 顯示重複發票摘要（最多列出前 10 組），完全重複與金額不同的發票分開計數
+func displayDuplicateReport(report *core.DuplicateReport) {
+	if !report.HasDuplicates() {
+		fmt.Println("✓ 未發現重複發票")
+		return
+	}
+
+	fmt.Printf("⚠ 發現 %d 張重複發票：%s %d 張、%s %d 張、%s %d 張\n", len(report.Groups),
+		core.DuplicateExact, report.Count(core.DuplicateExact),
+		core.DuplicateSameAmount, report.Count(core.DuplicateSameAmount),
+		core.DuplicateNear, report.Count(core.DuplicateNear))
+	displayLimit := 10
+	if len(report.Groups) < displayLimit {
+		displayLimit = len(report.Groups)
+	}
+	for i := 0; i < displayLimit; i++ {
+		fmt.Printf("  - %s\n", report.Groups[i])
+	}
+	if len(report.Groups) > 10 {
+		fmt.Printf("  ... 以及其他 %d 張\n", len(report.Groups)-10)
+	}
+}
+
+// displaySummaryRangeIssues 顯示彙總登錄範圍的問題（最多列出前 10 個）
+func displaySummaryRangeIssues(issues []core.ValidationIssue) {
+	if len(issues) == 0 {
+		return
+	}
+
+	fmt.Printf("⚠ 彙總登錄範圍發現 %d 個問題（將列入驗證報告）\n", len(issues))
+	displayLimit := 10
+	if len(issues) < displayLimit {
+		displayLimit = len(issues)
+	}
+	for i := 0; i < displayLimit; i++ {
+		fmt.Printf("  - %s\n", issues[i])
+	}
+	if len(issues) > 10 {
+		fmt.Printf("  ... 以及其他 %d 個問題\n", len(issues)-10)
+	}
+}
+
+// selectFolderAndFiles 選擇資料夾並取得 TXT 檔案
+func selectFolderAndFiles() (string, []string, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println("請輸入要處理的資料夾路徑（或直接拖曳資料夾）：")
+	fmt.Print("> ")
+
+	folderPath, err := reader.ReadString('\n')
+	if err != nil {
+		return "", nil, err
+	}
+
+	folderPath = strings.TrimSpace(folderPath)
+	folderPath = strings.Trim(folderPath, "\"") // 移除拖曳時的引號
+
+	// 檢查資料夾是否存在
+	info, err := os.Stat(folderPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("資料夾不存在或無法存取")
+	}
+
+	if !info.IsDir() {
+		return "", nil, fmt.Errorf("路徑不是資料夾")
+	}
+
+	// 取得所有 TXT 檔案
+	txtFiles, err := filepath.Glob(filepath.Join(folderPath, "*.txt"))
+	if err != nil {
+		return "", nil, err
+	}
+
+	return folderPath, txtFiles, nil
+}
+
+// getUserParameters 取得使用者參數
+func getUserParameters() (int, int) {
+	reader := bufio.NewReader(os.Stdin)
+
+	// 取得每個 Excel 最大列數
+	var maxRowsPerExcel int
+	for {
+		fmt.Println()
+		fmt.Printf("請輸入每個 Excel 檔案的最大資料筆數（不含標題列）(預設: %d): ", core.MaxExcelDataRows)
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			maxRowsPerExcel = core.MaxExcelDataRows
+			break
+		}
+
+		val, err := strconv.Atoi(input)
+		if err != nil || val <= 0 {
+			fmt.Println("請輸入有效的正整數")
+			continue
+		}
+		if val > core.MaxExcelDataRows {
+			fmt.Printf("不可超過 %d（Excel 工作表列數上限扣除標題列）\n", core.MaxExcelDataRows)
+			continue
+		}
+
+		maxRowsPerExcel = val
+		break
+	}
+
+	// 取得期望的 Excel 檔案個數
+	var desiredExcelCount int
+	for {
+		fmt.Print("請輸入期望產出的 Excel 檔案個數 (預設: 10): ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			desiredExcelCount = 10
+			break
+		}
+
+		val, err := strconv.Atoi(input)
+		if err != nil || val <= 0 {
+			fmt.Println("請輸入有效的正整數")
+			continue
+		}
+
+		desiredExcelCount = val
+		break
+	}
+
+	return maxRowsPerExcel, desiredExcelCount
+}
+
+// 功能選項
+const (
+	functionMerge      = "merge"
+	functionExcelToTxt = "excel-to-txt"
+)
+
+// selectFunction 選擇功能
+func selectFunction() string {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println("請選擇功能：")
+		fmt.Println("  1. 合併 TXT 並匯出（預設）")
+		fmt.Println("  2. Excel 轉回申報 TXT")
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		switch input {
+		case "", "1":
+			return functionMerge
+		case "2":
+			return functionExcelToTxt
+		}
+		fmt.Println("請輸入 1 或 2")
+		fmt.Println()
+	}
+}
+
+// runExcelToTxt 將修正後的 Excel 轉回申報 TXT（輸出於同一資料夾）
+func runExcelToTxt() {
+	reader := bufio.NewReader(os.Stdin)
+
+	fmt.Println()
+	fmt.Println("請輸入要轉換的 Excel 檔案路徑（或直接拖曳檔案）：")
+	fmt.Print("> ")
+	xlsxPath, err := reader.ReadString('\n')
+	if err != nil {
+		fmt.Printf("錯誤: %v\n", err)
+		return
+	}
+	xlsxPath = strings.Trim(strings.TrimSpace(xlsxPath), "\"")
+
+	if _, err := os.Stat(xlsxPath); err != nil {
+		fmt.Println("錯誤: 檔案不存在或無法存取")
+		return
+	}
+
+	outputPath := strings.TrimSuffix(xlsxPath, filepath.Ext(xlsxPath)) + "_轉出.txt"
+
+	fmt.Println()
+	convertErrors, err := core.ImportExcelToTxt(xlsxPath, outputPath)
+	if err != nil {
+		fmt.Printf("❌ 轉換失敗：%v\n", err)
+		return
+	}
+
+	fmt.Printf("   輸出位置: %s\n", outputPath)
+	if len(convertErrors) > 0 {
+		fmt.Println()
+		fmt.Printf("⚠ %d 個儲存格無法轉換，所在資料列未寫入：\n", len(convertErrors))
+		for _, convertError := range convertErrors {
+			fmt.Printf("  - %v\n", convertError)
+		}
+	}
+}
+
+// selectAllocationStrategy 選擇分配策略
+func selectAllocationStrategy() core.AllocationStrategy {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println()
+		fmt.Println("請選擇分配策略：")
+		for i, strategy := range core.AllocationStrategies {
+			if i == 0 {
+				fmt.Printf("  %d. %s（預設）\n", i+1, strategy)
+			} else {
+				fmt.Printf("  %d. %s\n", i+1, strategy)
+			}
+		}
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return core.AllocationStrategies[0]
+		}
+		val, err := strconv.Atoi(input)
+		if err == nil && val >= 1 && val <= len(core.AllocationStrategies) {
+			return core.AllocationStrategies[val-1]
+		}
+		fmt.Printf("請輸入 1 到 %d\n", len(core.AllocationStrategies))
+	}
+}
+
+// selectColumnProfile 選擇匯出欄位設定（內建設定或 JSON/YAML 設定檔）
+func selectColumnProfile() *core.ColumnProfile {
+	reader := bufio.NewReader(os.Stdin)
+	custom := len(core.ColumnProfiles) + 1
+
+	for {
+		fmt.Println()
+		fmt.Println("請選擇匯出欄位：")
+		for i, profile := range core.ColumnProfiles {
+			if i == 0 {
+				fmt.Printf("  %d. %s（預設）\n", i+1, profile.Name)
+			} else {
+				fmt.Printf("  %d. %s\n", i+1, profile.Name)
+			}
+		}
+		fmt.Printf("  %d. 自訂（JSON 或 YAML 設定檔）\n", custom)
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return core.ColumnProfiles[0]
+		}
+		val, err := strconv.Atoi(input)
+		if err == nil && val >= 1 && val <= len(core.ColumnProfiles) {
+			return core.ColumnProfiles[val-1]
+		}
+		if val != custom {
+			fmt.Printf("請輸入 1 到 %d\n", custom)
+			continue
+		}
+
+		fmt.Print("請輸入或拖曳設定檔路徑: ")
+		path, _ := reader.ReadString('\n')
+		path = strings.Trim(strings.TrimSpace(path), `"'`)
+		profile, err := core.LoadColumnProfile(path)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			continue
+		}
+		fmt.Printf("✓ 已載入欄位設定「%s」（%d 欄）\n", profile.Name, len(profile.Columns))
+		return profile
+	}
+}
+
+// selectGroupBy 選擇分組依據
+func selectGroupBy() core.GroupBy {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println()
+		fmt.Println("請選擇分組方式（每組各自產出 Excel，檔名包含分組值）：")
+		for i, groupBy := range core.GroupByOptions {
+			if i == 0 {
+				fmt.Printf("  %d. %s（預設）\n", i+1, groupBy)
+			} else {
+				fmt.Printf("  %d. 依%s\n", i+1, groupBy)
+			}
+		}
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return core.GroupByOptions[0]
+		}
+		val, err := strconv.Atoi(input)
+		if err == nil && val >= 1 && val <= len(core.GroupByOptions) {
+			return core.GroupByOptions[val-1]
+		}
+		fmt.Printf("請輸入 1 到 %d\n", len(core.GroupByOptions))
+	}
+}
+
+// 輸出格式
+const (
+	outputModeExcel    = "excel"
+	outputModeWorkbook = "workbook"
+	outputModeTxt      = "txt"
+	outputModeCSV      = "csv"
+	outputModeJSONL    = "jsonl"
+)
+
+// selectOutputMode 選擇輸出格式
+func selectOutputMode() string {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Println()
+		fmt.Println("請選擇輸出格式：")
+		fmt.Println("  1. Excel（預設）")
+		fmt.Println("  2. 合併為申報 TXT（媒體申報檔）")
+		fmt.Println("  3. 單一 Excel（每個分配一個工作表，含目錄）")
+		fmt.Println("  4. CSV")
+		fmt.Println("  5. JSON Lines")
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		switch input {
+		case "", "1":
+			return outputModeExcel
+		case "2":
+			return outputModeTxt
+		case "3":
+			return outputModeWorkbook
+		case "4":
+			return outputModeCSV
+		case "5":
+			return outputModeJSONL
+		}
+		fmt.Println("請輸入 1 到 5")
+	}
+}
+
+// newExporter 依輸出格式建立匯出器，申報 TXT 詢問是否重編流水號，CSV 詢問輸出編碼
+// provenance 為 true 時在欄位設定後附加來源檔案與行號（申報 TXT 為固定長度格式，不適用）；periods 為 Excel 輸出使用的整期計算結果
+func newExporter(outputMode string, profile *core.ColumnProfile, provenance bool, maxRowsPerExcel int, validation core.ValidationOptions, periods *core.PeriodReport) core.Exporter {
+	switch outputMode {
+	case outputModeTxt:
+		fmt.Print("是否依申報營業人重新編列流水號？(y/n): ")
+		return &core.TxtExporter{Renumber: confirmYes(), Validation: validation}
+	case outputModeWorkbook:
+		return &core.WorkbookExporter{Profile: profile, MaxRows: maxRowsPerExcel, Validation: validation, OmitProvenance: !provenance, Periods: periods}
+	case outputModeCSV:
+		return &core.CSVExporter{Profile: profile, Encoding: selectCSVEncoding(), Workers: core.DefaultWorkers(), Validation: validation, OmitProvenance: !provenance}
+	case outputModeJSONL:
+		return &core.JSONLinesExporter{Profile: profile, Workers: core.DefaultWorkers(), Validation: validation, OmitProvenance: !provenance}
+	default:
+		return &core.ExcelExporter{Profile: profile, MaxRows: maxRowsPerExcel, Workers: core.DefaultWorkers(), Validation: validation, OmitProvenance: !provenance, Periods: periods}
+	}
+}
+
+// selectTaxTolerance 輸入營業稅額與銷售金額 × 稅率容許的差額（每張發票）
+func selectTaxTolerance() int64 {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Printf("請輸入營業稅額容許的差額（元，每張發票，彙總登錄依張數累加）(預設: %d): ", core.DefaultTaxTolerance)
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return core.DefaultTaxTolerance
+		}
+
+		val, err := strconv.ParseInt(input, 10, 64)
+		if err != nil || val < 0 {
+			fmt.Println("請輸入有效的非負整數")
+			continue
+		}
+		return val
+	}
+}
+
+// selectCSVEncoding 選擇 CSV 輸出編碼
+func selectCSVEncoding() core.FileEncoding {
+	reader := bufio.NewReader(os.Stdin)
+	encodings := []core.FileEncoding{core.EncodingUTF8BOM, core.EncodingUTF8, core.EncodingBig5}
+
+	for {
+		fmt.Println("請選擇 CSV 編碼：")
+		for i, encoding := range encodings {
+			if i == 0 {
+				fmt.Printf("  %d. %s（預設，Excel 可直接開啟）\n", i+1, encoding)
+			} else {
+				fmt.Printf("  %d. %s\n", i+1, encoding)
+			}
+		}
+		fmt.Print("> ")
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+
+		if input == "" {
+			return encodings[0]
+		}
+		val, err := strconv.Atoi(input)
+		if err == nil && val >= 1 && val <= len(encodings) {
+			return encodings[val-1]
+		}
+		fmt.Printf("請輸入 1 到 %d\n", len(encodings))
+	}
+}
+
+// confirmYes 確認是否為 yes
+func confirmYes() bool {
+	reader := bufio.NewReader(os.Stdin)
+	response, _ := reader.ReadString('\n')
+	response = strings.ToLower(strings.TrimSpace(response))
+	return response == "y" || response == "yes"
+}
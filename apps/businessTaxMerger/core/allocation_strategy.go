@@ -0,0 +1,260 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AllocationStrategy 檔案分配到 Excel 的策略（檔案皆不可分割）
+type AllocationStrategy int
+
+const (
+	// StrategyNextFit 依資料夾順序填入，放不下就開新的 Excel（保留檔案順序）
+	StrategyNextFit AllocationStrategy = iota
+	// StrategyFirstFitDecreasing 由大到小放入第一個放得下的 Excel，使用最少的 Excel 個數
+	StrategyFirstFitDecreasing
+	// StrategyBalanced 平均分配到剛好期望個數的 Excel
+	StrategyBalanced
+)
+
+// AllocationStrategies 所有可選擇的分配策略（依選單順序）
+var AllocationStrategies = []AllocationStrategy{
+	StrategyNextFit,
+	StrategyFirstFitDecreasing,
+	StrategyBalanced,
+}
+
+// String 策略名稱
+func (s AllocationStrategy) String() string {
+	switch s {
+	case StrategyNextFit:
+		return "依序填滿"
+	case StrategyFirstFitDecreasing:
+		return "最少檔案數"
+	case StrategyBalanced:
+		return "平均分配"
+	default:
+		return "未知策略"
+	}
+}
+
+// allocateFiles 依策略分配檔案；平均分配需要 desiredExcelCount，無法分配時回傳錯誤
+// splitOversized 為 true 時，超過最大列數的檔案拆分到連續的 Excel（僅支援依序填滿）
+// 已分組的清單（GroupFiles）每組各自分配，不同組不會放在同一個 Excel
+// maxRowsPerExcel 超過工作表可容納的資料筆數時以 MaxExcelDataRows 為準（第一列為標題）
+func allocateFiles(strategy AllocationStrategy, fileInfoList []*TxtFileInfo, maxRowsPerExcel int, desiredExcelCount int, splitOversized bool) ([][]*TxtFileInfo, error) {
+	maxRowsPerExcel = excelDataRows(maxRowsPerExcel)
+	if len(fileInfoList) == 0 || fileInfoList[0].GroupBy == GroupByNone {
+		return allocateGroup(strategy, fileInfoList, maxRowsPerExcel, desiredExcelCount, splitOversized)
+	}
+
+	if strategy == StrategyBalanced {
+		return nil, fmt.Errorf("依%s分組時，每組的 Excel 個數由資料量決定，不支援「%s」策略", fileInfoList[0].GroupBy, StrategyBalanced)
+	}
+
+	allocation := make([][]*TxtFileInfo, 0)
+	for _, group := range partitionByGroup(fileInfoList) {
+		groupAllocation, err := allocateGroup(strategy, group, maxRowsPerExcel, desiredExcelCount, splitOversized)
+		if err != nil {
+			return nil, err
+		}
+		allocation = append(allocation, groupAllocation...)
+	}
+	return allocation, nil
+}
+
+// allocateGroup 依策略分配同一組的檔案
+func allocateGroup(strategy AllocationStrategy, fileInfoList []*TxtFileInfo, maxRowsPerExcel int, desiredExcelCount int, splitOversized bool) ([][]*TxtFileInfo, error) {
+	// 檢查是否有任何單一檔案超過最大列數限制
+	for _, fileInfo := range fileInfoList {
+		if fileInfo.RecordCount <= maxRowsPerExcel {
+			continue
+		}
+		if !splitOversized {
+			return nil, fmt.Errorf(
+				"檔案 '%s' 有 %d 筆資料，超過單一 Excel 最大列數限制 %d 行，此檔案無法處理（可啟用拆分大檔案）",
+				fileInfo.FileName,
+				fileInfo.RecordCount,
+				maxRowsPerExcel,
+			)
+		}
+		if strategy != StrategyNextFit {
+			return nil, fmt.Errorf(
+				"檔案 '%s' 超過最大列數需要拆分，拆分後的片段須放在連續的 Excel，僅支援「%s」策略",
+				fileInfo.FileName,
+				StrategyNextFit,
+			)
+		}
+		return splitFileAllocation(fileInfoList, maxRowsPerExcel), nil
+	}
+
+	switch strategy {
+	case StrategyNextFit:
+		return simulateFileAllocation(fileInfoList, maxRowsPerExcel), nil
+	case StrategyFirstFitDecreasing:
+		return firstFitDecreasingAllocation(fileInfoList, maxRowsPerExcel), nil
+	case StrategyBalanced:
+		return balancedAllocation(fileInfoList, maxRowsPerExcel, desiredExcelCount)
+	default:
+		return nil, fmt.Errorf("未知的分配策略: %d", strategy)
+	}
+}
+
+// firstFitDecreasingAllocation 最少檔案數分配（First-Fit Decreasing）
+// 規則：檔案依筆數由大到小，放入第一個放得下的 Excel，都放不下才開新的 Excel
+func firstFitDecreasingAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int) [][]*TxtFileInfo {
+	bins := make([][]int, 0)
+	binRows := make([]int, 0)
+
+	for _, index := range indexesByRecordCountDesc(fileInfoList) {
+		rows := fileInfoList[index].RecordCount
+		placed := false
+		for b := range bins {
+			if binRows[b]+rows <= maxRowsPerExcel {
+				bins[b] = append(bins[b], index)
+				binRows[b] += rows
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			bins = append(bins, []int{index})
+			binRows = append(binRows, rows)
+		}
+	}
+
+	return binsToAllocation(fileInfoList, bins)
+}
+
+// balancedAllocation 平均分配到剛好 excelCount 個 Excel
+// 規則：檔案依筆數由大到小，放入目前筆數最少且放得下的 Excel
+func balancedAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int, excelCount int) ([][]*TxtFileInfo, error) {
+	if excelCount <= 0 {
+		return nil, fmt.Errorf("平均分配需要指定期望的 Excel 檔案個數")
+	}
+	if len(fileInfoList) < excelCount {
+		return nil, fmt.Errorf(
+			"只有 %d 個檔案，檔案不可分割，無法平均分配到 %d 個 Excel 檔案",
+			len(fileInfoList),
+			excelCount,
+		)
+	}
+
+	bins := make([][]int, excelCount)
+	binRows := make([]int, excelCount)
+
+	for _, index := range indexesByRecordCountDesc(fileInfoList) {
+		fileInfo := fileInfoList[index]
+		target := -1
+		for b := range bins {
+			if binRows[b]+fileInfo.RecordCount > maxRowsPerExcel {
+				continue
+			}
+			if target < 0 || binRows[b] < binRows[target] {
+				target = b
+			}
+		}
+		if target < 0 {
+			return nil, fmt.Errorf(
+				"%d 個 Excel 檔案無法容納所有資料（檔案 '%s' 放不進任何 Excel），請增加檔案個數或最大列數限制",
+				excelCount,
+				fileInfo.FileName,
+			)
+		}
+		bins[target] = append(bins[target], index)
+		binRows[target] += fileInfo.RecordCount
+	}
+
+	return binsToAllocation(fileInfoList, bins), nil
+}
+
+// indexesByRecordCountDesc 依資料筆數由大到小排列的檔案索引（筆數相同時維持原順序）
+func indexesByRecordCountDesc(fileInfoList []*TxtFileInfo) []int {
+	indexes := make([]int, len(fileInfoList))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return fileInfoList[indexes[a]].RecordCount > fileInfoList[indexes[b]].RecordCount
+	})
+	return indexes
+}
+
+// binsToAllocation 將索引分組轉為分配結果，每個 Excel 內的檔案維持資料夾順序
+func binsToAllocation(fileInfoList []*TxtFileInfo, bins [][]int) [][]*TxtFileInfo {
+	allocation := make([][]*TxtFileInfo, 0, len(bins))
+	for _, bin := range bins {
+		sort.Ints(bin)
+		excelFiles := make([]*TxtFileInfo, len(bin))
+		for i, index := range bin {
+			excelFiles[i] = fileInfoList[index]
+		}
+		allocation = append(allocation, excelFiles)
+	}
+	return allocation
+}
+
+// DisplayStrategyComparison 並列比較各分配策略的結果（Excel 個數、最多/最少列數、是否符合期望個數）
+// splitOversized 與 ValidateAndAllocateFiles 相同
+func DisplayStrategyComparison(fileInfoList []*TxtFileInfo, maxRowsPerExcel int, desiredExcelCount int, splitOversized bool) {
+	fmt.Println()
+	fmt.Println("═══════════════════════════════════════════════════")
+	fmt.Printf("分配策略比較（每個 Excel 最多 %d 列，期望 %d 個檔案）：\n", maxRowsPerExcel, desiredExcelCount)
+	fmt.Println("═══════════════════════════════════════════════════")
+	fmt.Printf("  %-3s %s %s %s %s  %s\n", "#", padDisplay("策略", 12), padDisplay("Excel 數", 10), padDisplay("最多列數", 10), padDisplay("最少列數", 10), "結果")
+
+	for i, strategy := range AllocationStrategies {
+		allocation, err := allocateFiles(strategy, fileInfoList, maxRowsPerExcel, desiredExcelCount, splitOversized)
+		if err == nil {
+			err = checkAllocation(allocation, desiredExcelCount, strategy)
+		}
+		if allocation == nil {
+			fmt.Printf("  %-3d %s %-10s %-10s %-10s  ✗ 無法分配\n", i+1, padDisplay(strategy.String(), 12), "-", "-", "-")
+			continue
+		}
+
+		maxRows, minRows := allocationRowRange(allocation)
+		result := "✓ 符合"
+		if err != nil {
+			result = "✗ 超過期望個數"
+		}
+		fmt.Printf("  %-3d %s %-10d %-10d %-10d  %s\n", i+1, padDisplay(strategy.String(), 12), len(allocation), maxRows, minRows, result)
+	}
+
+	fmt.Println("═══════════════════════════════════════════════════")
+}
+
+// allocationRowRange 各 Excel 資料筆數的最大值與最小值
+func allocationRowRange(allocation [][]*TxtFileInfo) (int, int) {
+	maxRows, minRows := 0, 0
+	for i, excelFiles := range allocation {
+		rows := 0
+		for _, f := range excelFiles {
+			rows += f.RecordCount
+		}
+		if i == 0 || rows > maxRows {
+			maxRows = rows
+		}
+		if i == 0 || rows < minRows {
+			minRows = rows
+		}
+	}
+	return maxRows, minRows
+}
+
+// padDisplay 以終端機顯示寬度補空白（全形字佔 2 格）
+func padDisplay(text string, width int) string {
+	displayWidth := 0
+	for _, r := range text {
+		if r > 0x7F {
+			displayWidth += 2
+		} else {
+			displayWidth++
+		}
+	}
+	if displayWidth >= width {
+		return text
+	}
+	return text + strings.Repeat(" ", width-displayWidth)
+}
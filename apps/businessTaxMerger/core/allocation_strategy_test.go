@@ -0,0 +1,145 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// strategyFiles 依「檔名:筆數」建立分配用的檔案清單（分配只看筆數，不需要暫存檔）
+func strategyFiles(counts map[string]int, names ...string) []*TxtFileInfo {
+	files := make([]*TxtFileInfo, len(names))
+	for i, name := range names {
+		files[i] = &TxtFileInfo{FileName: name, RecordCount: counts[name]}
+	}
+	return files
+}
+
+// allocationNames 分配結果中各 Excel 的檔名
+func allocationNames(allocation [][]*TxtFileInfo) [][]string {
+	names := make([][]string, len(allocation))
+	for i, excelFiles := range allocation {
+		for _, fileInfo := range excelFiles {
+			names[i] = append(names[i], fileInfo.FileName)
+		}
+	}
+	return names
+}
+
+func TestAllocationStrategies(t *testing.T) {
+	counts := map[string]int{"a": 3, "b": 8, "c": 5, "d": 2, "e": 4, "f": 10, "g": 11, "h": 5, "i": 5, "j": 5}
+
+	cases := []struct {
+		name     string
+		strategy AllocationStrategy
+		files    []string
+		maxRows  int
+		count    int
+		want     [][]string
+		// wantErr 錯誤訊息應包含的文字（空字串表示不應有錯誤）
+		wantErr string
+	}{
+		{
+			// 由大到小：b→1、c→2、e→2、a→3、d→1；各 Excel 內維持資料夾順序
+			name: "最少檔案數", strategy: StrategyFirstFitDecreasing,
+			files: []string{"a", "b", "c", "d", "e"}, maxRows: 10, count: 3,
+			want: [][]string{{"b", "d"}, {"c", "e"}, {"a"}},
+		},
+		{
+			name: "最少檔案數剛好填滿", strategy: StrategyFirstFitDecreasing,
+			files: []string{"f", "b", "d"}, maxRows: 10, count: 2,
+			want: [][]string{{"f"}, {"b", "d"}},
+		},
+		{
+			name: "最少檔案數超過期望個數", strategy: StrategyFirstFitDecreasing,
+			files: []string{"a", "b", "c", "d", "e"}, maxRows: 10, count: 2,
+			wantErr: "需要至少 3 個 Excel",
+		},
+		{
+			name: "最少檔案數單一檔案超過最大列數", strategy: StrategyFirstFitDecreasing,
+			files: []string{"a", "g"}, maxRows: 10, count: 3,
+			wantErr: "檔案 'g' 有 11 筆資料",
+		},
+		{
+			// 檔案多於 Excel 個數：b→1、c→2、e→2、a→1（筆數較少）、d→2（1 已放不下）
+			name: "平均分配", strategy: StrategyBalanced,
+			files: []string{"a", "b", "c", "d", "e"}, maxRows: 12, count: 2,
+			want: [][]string{{"a", "b"}, {"c", "d", "e"}},
+		},
+		{
+			name: "平均分配筆數相同時輪流放入", strategy: StrategyBalanced,
+			files: []string{"c", "h", "i", "j"}, maxRows: 10, count: 2,
+			want: [][]string{{"c", "i"}, {"h", "j"}},
+		},
+		{
+			// Excel 編號依放入順序（由大到小），不是資料夾順序
+			name: "平均分配每個 Excel 至少一個檔案", strategy: StrategyBalanced,
+			files: []string{"a", "b", "c"}, maxRows: 10, count: 3,
+			want: [][]string{{"b"}, {"c"}, {"a"}},
+		},
+		{
+			name: "平均分配容納不下", strategy: StrategyBalanced,
+			files: []string{"a", "b", "c", "d", "e"}, maxRows: 10, count: 2,
+			wantErr: "2 個 Excel 檔案無法容納所有資料",
+		},
+		{
+			name: "平均分配檔案少於期望個數", strategy: StrategyBalanced,
+			files: []string{"a", "b"}, maxRows: 10, count: 3,
+			wantErr: "無法平均分配到 3 個 Excel",
+		},
+		{
+			name: "平均分配單一檔案超過最大列數", strategy: StrategyBalanced,
+			files: []string{"a", "g"}, maxRows: 10, count: 2,
+			wantErr: "檔案 'g' 有 11 筆資料",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			files := strategyFiles(counts, tc.files...)
+			allocation, err := ValidateAndAllocateFiles(context.Background(), files, tc.maxRows, tc.count, tc.strategy, false)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("錯誤 = %v，應包含 %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := allocationNames(allocation); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("分配結果 = %v，應為 %v", got, tc.want)
+			}
+
+			// 每個 Excel 不超過最大列數，每個檔案恰好分配一次
+			seen := make(map[string]int)
+			for i, excelFiles := range allocation {
+				rows := 0
+				for _, fileInfo := range excelFiles {
+					rows += fileInfo.RecordCount
+					seen[fileInfo.FileName]++
+				}
+				if rows > tc.maxRows {
+					t.Errorf("第 %d 個 Excel 有 %d 筆，超過最大列數 %d", i+1, rows, tc.maxRows)
+				}
+			}
+			for _, name := range tc.files {
+				if seen[name] != 1 {
+					t.Errorf("檔案 %s 分配 %d 次，應為 1 次", name, seen[name])
+				}
+			}
+		})
+	}
+}
+
+func TestAllocationStrategiesRejectSplit(t *testing.T) {
+	files := strategyFiles(map[string]int{"a": 3, "g": 11}, "a", "g")
+	for _, strategy := range []AllocationStrategy{StrategyFirstFitDecreasing, StrategyBalanced} {
+		t.Run(strategy.String(), func(t *testing.T) {
+			_, err := ValidateAndAllocateFiles(context.Background(), files, 10, 2, strategy, true)
+			if err == nil || !strings.Contains(err.Error(), "僅支援「"+StrategyNextFit.String()+"」策略") {
+				t.Errorf("錯誤 = %v，拆分大檔案應只支援「%s」", err, StrategyNextFit)
+			}
+		})
+	}
+}
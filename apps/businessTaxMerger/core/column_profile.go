@@ -0,0 +1,244 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ColumnType 匯出欄位的資料型態
+type ColumnType string
+
+const (
+	// ColumnText 文字（保留前導零）
+	ColumnText ColumnType = "text"
+	// ColumnNumber 數字
+	ColumnNumber ColumnType = "number"
+)
+
+// defaultColumnWidth 未指定欄寬時的預設欄寬
+const defaultColumnWidth = 15
+
+// 合併欄位：依資料類別取用對應的 TaxRecord 欄位
+const (
+	// columnVoucher 發票字軌 + 發票(起)號碼（其他憑證、海關繳納證為各自的號碼）
+	columnVoucher = "Voucher"
+	// columnAmount 銷售金額（海關繳納證為營業稅稅基）
+	columnAmount = "Amount"
+)
+
+// ExportColumn 匯出的一個欄位
+type ExportColumn struct {
+	// Field TaxRecord 欄位名稱，另可用 Voucher、Amount、SourceFileName、LineNumber
+	Field string `json:"field" yaml:"field"`
+	// Header 標題文字，空白時使用欄位中文名稱
+	Header string `json:"header,omitempty" yaml:"header,omitempty"`
+	// Type 資料型態（text 或 number），空白時依欄位預設
+	Type ColumnType `json:"type,omitempty" yaml:"type,omitempty"`
+	// Width 欄寬，0 時為預設欄寬
+	Width float64 `json:"width,omitempty" yaml:"width,omitempty"`
+}
+
+// ColumnProfile 匯出欄位設定：要匯出的欄位、順序、標題、型態與欄寬
+// 標題文字修改後，Excel 轉回 TXT 時無法辨識該欄位，需要轉回時請保留預設標題
+type ColumnProfile struct {
+	Name    string         `json:"name" yaml:"name"`
+	Columns []ExportColumn `json:"columns" yaml:"columns"`
+}
+
+// exportField 可匯出欄位的取值方式
+type exportField struct {
+	// value 取得欄位內容
+	value func(record *TaxRecord) string
+	// validated 驗證失敗時需標示此欄的 TaxRecord 欄位
+	validated []string
+	// columnType 預設資料型態
+	columnType ColumnType
+	// integer 以整數格式顯示（非金額的數字，例如行號）
+	integer bool
+	// header 預設標題
+	header string
+}
+
+// exportFields 可匯出的欄位（TaxRecord 字串欄位、合併欄位與來源資訊）
+var exportFields = buildExportFields()
+
+// buildExportFields 建立可匯出欄位表
+func buildExportFields() map[string]exportField {
+	fields := make(map[string]exportField, len(recordFields)+4)
+	for name, accessor := range recordFields {
+		columnType := ColumnText
+		amount := name == "SalesAmount" || name == "TaxBase" || name == "TaxAmount"
+		if amount {
+			columnType = ColumnNumber
+		}
+		fields[name] = exportField{
+			value:      func(record *TaxRecord) string { return *accessor(record) },
+			validated:  []string{name},
+			columnType: columnType,
+			integer:    !amount,
+			header:     FieldLabel(name),
+		}
+	}
+
+	fields[columnVoucher] = exportField{
+		value:      (*TaxRecord).VoucherNumber,
+		validated:  []string{"InvoicePrefix", "InvoiceStartNumber", "OtherVoucherNumber", "UtilitySequenceNumber", "CustomsTaxPaymentNumber"},
+		columnType: ColumnText,
+		header:     voucherHeader,
+	}
+	fields[columnAmount] = exportField{
+		value:      (*TaxRecord).Amount,
+		validated:  []string{"SalesAmount", "TaxBase"},
+		columnType: ColumnNumber,
+		header:     amountHeader,
+	}
+	fields["SourceFileName"] = exportField{
+		value:      func(record *TaxRecord) string { return record.SourceFileName },
+		columnType: ColumnText,
+		header:     FieldLabel("SourceFileName"),
+	}
+	fields["LineNumber"] = exportField{
+		value:      func(record *TaxRecord) string { return strconv.Itoa(record.LineNumber) },
+		columnType: ColumnNumber,
+		integer:    true,
+		header:     FieldLabel("LineNumber"),
+	}
+	return fields
+}
+
+// standardColumns 標準欄位（與申報檔欄位順序相同）
+// 空白欄位一併匯出：原始檔在這些位置有內容（例如備註）時，Excel 轉回 TXT 不會遺失
+var standardColumns = []ExportColumn{
+	{Field: "FormatCode"},
+	{Field: "DeclarantTaxId"},
+	{Field: "SequenceNumber"},
+	{Field: "DataYear"},
+	{Field: "DataMonth"},
+	{Field: "BuyerTaxId"},
+	{Field: "BusinessNumber"}, // 僅彙總登錄
+	{Field: "TotalSheets"},    // 僅彙總登錄
+	{Field: "Blank2"},         // 僅海關繳納證
+	{Field: "Blank1"},         // 僅彙總登錄
+	{Field: "SellerTaxId"},
+	{Field: columnVoucher},
+	{Field: columnAmount},
+	{Field: "TaxType"},
+	{Field: "TaxAmount"},
+	{Field: "DeductionCode"},
+	{Field: "Blank3"},
+	{Field: "SpecialTaxRate"},
+	{Field: "AggregationMark"}, // 僅銷項
+	{Field: "AllocationMark"},  // 僅進項
+	{Field: "CustomsClearanceMark"},
+}
+
+// DefaultColumnProfile 預設欄位設定
+var DefaultColumnProfile = &ColumnProfile{Name: "標準", Columns: standardColumns}
+
+// ColumnProfiles 內建欄位設定（第一個為預設）
+var ColumnProfiles = []*ColumnProfile{
+	DefaultColumnProfile,
+	{
+		// 海關：進口貨物的繳納證號碼、稅基與通關方式
+		Name: "海關",
+		Columns: []ExportColumn{
+			{Field: "FormatCode"},
+			{Field: "DeclarantTaxId"},
+			{Field: "DataYear"},
+			{Field: "DataMonth"},
+			{Field: "BuyerTaxId"},
+			{Field: "Blank2"},
+			{Field: columnVoucher, Width: 22},
+			{Field: columnAmount},
+			{Field: "TaxType"},
+			{Field: "TaxAmount"},
+			{Field: "DeductionCode"},
+			{Field: "Blank3"},
+			{Field: "CustomsClearanceMark"},
+		},
+	},
+	{
+		// 查核：標準欄位加上來源檔案與行號
+		Name: "查核",
+		Columns: append(append([]ExportColumn{}, standardColumns...),
+			ExportColumn{Field: "SourceFileName", Width: 30},
+			ExportColumn{Field: "LineNumber", Width: 10},
+		),
+	},
+}
+
+// LoadColumnProfile 由 JSON 或 YAML（副檔名 .yaml、.yml）檔案讀取欄位設定
+func LoadColumnProfile(path string) (*ColumnProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取欄位設定失敗: %v", err)
+	}
+
+	profile := &ColumnProfile{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, profile)
+	default:
+		err = json.Unmarshal(data, profile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("欄位設定格式錯誤: %v", err)
+	}
+	if profile.Name == "" {
+		profile.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if err := profile.validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// validate 檢查欄位設定：至少一個欄位、欄位名稱與型態有效、欄寬不為負數
+func (p *ColumnProfile) validate() error {
+	if len(p.Columns) == 0 {
+		return fmt.Errorf("欄位設定「%s」沒有任何欄位", p.Name)
+	}
+	for i, column := range p.Columns {
+		if _, ok := exportFields[column.Field]; !ok {
+			return fmt.Errorf("欄位設定「%s」第 %d 欄：未知的欄位 %q", p.Name, i+1, column.Field)
+		}
+		if column.Type != "" && column.Type != ColumnText && column.Type != ColumnNumber {
+			return fmt.Errorf("欄位設定「%s」第 %d 欄：型態應為 text 或 number", p.Name, i+1)
+		}
+		if column.Width < 0 {
+			return fmt.Errorf("欄位設定「%s」第 %d 欄：欄寬不可為負數", p.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// header 欄位標題
+func (c ExportColumn) header() string {
+	if c.Header != "" {
+		return c.Header
+	}
+	return exportFields[c.Field].header
+}
+
+// columnType 欄位資料型態
+func (c ExportColumn) columnType() ColumnType {
+	if c.Type != "" {
+		return c.Type
+	}
+	return exportFields[c.Field].columnType
+}
+
+// width 欄寬
+func (c ExportColumn) width() float64 {
+	if c.Width > 0 {
+		return c.Width
+	}
+	return defaultColumnWidth
+}
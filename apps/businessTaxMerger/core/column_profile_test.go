@@ -0,0 +1,126 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadColumnProfile(t *testing.T) {
+	wantColumns := []ExportColumn{
+		{Field: "FormatCode"},
+		{Field: columnVoucher, Header: "憑證號碼", Width: 20},
+		{Field: columnAmount, Type: ColumnNumber},
+		{Field: "LineNumber"},
+	}
+
+	cases := []struct {
+		name     string
+		fileName string
+		content  string
+		wantName string
+	}{
+		{
+			name:     "JSON",
+			fileName: "audit.json",
+			content: `{
+  "name": "查核用",
+  "columns": [
+    { "field": "FormatCode" },
+    { "field": "Voucher", "header": "憑證號碼", "width": 20 },
+    { "field": "Amount", "type": "number" },
+    { "field": "LineNumber" }
+  ]
+}`,
+			wantName: "查核用",
+		},
+		{
+			name:     "YAML",
+			fileName: "audit.yaml",
+			content: `name: 查核用
+columns:
+  - field: FormatCode
+  - field: Voucher
+    header: 憑證號碼
+    width: 20
+  - field: Amount
+    type: number
+  - field: LineNumber
+`,
+			wantName: "查核用",
+		},
+		{
+			name:     "副檔名 .yml 且未指定名稱時以檔名為名稱",
+			fileName: "audit.YML",
+			content: `columns:
+  - field: FormatCode
+  - {field: Voucher, header: 憑證號碼, width: 20}
+  - {field: Amount, type: number}
+  - field: LineNumber
+`,
+			wantName: "audit",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			profile, err := LoadColumnProfile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if profile.Name != tc.wantName {
+				t.Errorf("名稱 = %q，應為 %q", profile.Name, tc.wantName)
+			}
+			if !reflect.DeepEqual(profile.Columns, wantColumns) {
+				t.Errorf("欄位 = %+v，應為 %+v", profile.Columns, wantColumns)
+			}
+		})
+	}
+}
+
+func TestLoadColumnProfileRejectsInvalid(t *testing.T) {
+	cases := []struct {
+		name     string
+		fileName string
+		content  string
+		// wantErr 錯誤訊息應包含的文字
+		wantErr string
+	}{
+		{name: "未知的欄位", fileName: "p.json", content: `{"columns": [{"field": "FormatCode"}, {"field": "Remark"}]}`, wantErr: `第 2 欄：未知的欄位 "Remark"`},
+		{name: "YAML 未知的欄位", fileName: "p.yaml", content: "columns:\n  - field: formatCode\n", wantErr: `第 1 欄：未知的欄位 "formatCode"`},
+		{name: "型態錯誤", fileName: "p.json", content: `{"columns": [{"field": "TaxAmount", "type": "money"}]}`, wantErr: "型態應為 text 或 number"},
+		{name: "欄寬為負數", fileName: "p.json", content: `{"columns": [{"field": "TaxAmount", "width": -1}]}`, wantErr: "欄寬不可為負數"},
+		{name: "沒有欄位", fileName: "p.json", content: `{"name": "空白", "columns": []}`, wantErr: "沒有任何欄位"},
+		{name: "JSON 格式錯誤", fileName: "p.json", content: `{"columns": [`, wantErr: "欄位設定格式錯誤"},
+		{name: "YAML 格式錯誤", fileName: "p.yml", content: "columns: [field: FormatCode\n", wantErr: "欄位設定格式錯誤"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.fileName)
+			if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadColumnProfile(path)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("錯誤 = %v，應包含 %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadColumnProfile(filepath.Join(t.TempDir(), "missing.json")); err == nil || !strings.Contains(err.Error(), "讀取欄位設定失敗") {
+		t.Errorf("檔案不存在時錯誤 = %v，應為讀取失敗", err)
+	}
+}
+
+func TestBuiltinColumnProfilesValid(t *testing.T) {
+	for _, profile := range ColumnProfiles {
+		if err := profile.validate(); err != nil {
+			t.Errorf("內建欄位設定「%s」: %v", profile.Name, err)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package core
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/traditionalchinese"
+	"golang.org/x/text/transform"
+)
+
+// CSVExporter 每個分配各產出一個 CSV，欄位依欄位設定，可附加來源檔案與行號
+// 編碼可選 UTF-8、UTF-8 (BOM)（Excel 直接開啟不會亂碼）或 Big5
+// Big5 無法表示的字元以 ? 取代，並列入驗證報告
+type CSVExporter struct {
+	Profile        *ColumnProfile    // 欄位設定，nil 時使用預設欄位設定
+	Encoding       FileEncoding      // 輸出編碼
+	Workers        int               // 並行產出的檔案數，<= 0 表示使用 DefaultWorkers
+	Validation     ValidationOptions // 驗證選項
+	OmitProvenance bool              // 不附加來源檔案與行號欄位，完全依欄位設定（預設在欄位設定後附加）
+}
+
+// Name 輸出格式名稱
+func (e *CSVExporter) Name() string { return "CSV（" + e.Encoding.String() + "）" }
+
+// Export 匯出分配結果
+func (e *CSVExporter) Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error) {
+	profile := exportProfile(e.Profile, !e.OmitProvenance)
+	return exportRecordFiles(ctx, allocation, outputFolder, ".csv", e.Workers, e.Validation, func(w io.Writer) recordEncoder {
+		return newCSVEncoder(w, profile, e.Encoding)
+	})
+}
+
+// csvEncoder 以 CSV 逐筆寫出資料
+type csvEncoder struct {
+	out     io.Writer
+	big5    io.WriteCloser    // Big5 轉碼器（UTF-8 時為 nil）
+	runes   *encoding.Encoder // 逐字檢查能否以 Big5 表示（UTF-8 時為 nil）
+	writer  *csv.Writer
+	profile *ColumnProfile
+	bom     bool
+}
+
+// newCSVEncoder 建立 CSV 編碼器
+func newCSVEncoder(w io.Writer, profile *ColumnProfile, encoding FileEncoding) *csvEncoder {
+	e := &csvEncoder{out: w, profile: profile, bom: encoding == EncodingUTF8BOM}
+	if encoding == EncodingBig5 {
+		e.big5 = transform.NewWriter(w, traditionalchinese.Big5.NewEncoder())
+		e.runes = traditionalchinese.Big5.NewEncoder()
+		e.writer = csv.NewWriter(e.big5)
+	} else {
+		e.writer = csv.NewWriter(w)
+	}
+	// 使用 CRLF 換行，方便 Windows 上的 Excel 開啟
+	e.writer.UseCRLF = true
+	return e
+}
+
+// writeHeader 寫入 BOM（如有）與標題列
+func (e *csvEncoder) writeHeader() error {
+	if e.bom {
+		if _, err := e.out.Write(utf8BOM); err != nil {
+			return err
+		}
+	}
+	headers := make([]string, len(e.profile.Columns))
+	for i, column := range e.profile.Columns {
+		headers[i], _ = e.encodable(column.header())
+	}
+	return e.writer.Write(headers)
+}
+
+// writeRecord 寫入一筆資料（數字欄位去除前導零）
+// Big5 無法表示的字元以 ? 取代，每個被取代的欄位回傳一個問題
+func (e *csvEncoder) writeRecord(record *TaxRecord) ([]ValidationIssue, error) {
+	var issues []ValidationIssue
+	values := make([]string, len(e.profile.Columns))
+	for i, column := range e.profile.Columns {
+		value := exportFields[column.Field].value(record)
+		if column.columnType() == ColumnNumber {
+			value = strconv.FormatInt(parseAmountToInt(value), 10)
+		}
+		encodable, replaced := e.encodable(value)
+		if replaced {
+			issues = append(issues, ValidationIssue{
+				FileName:   record.SourceFileName,
+				LineNumber: record.LineNumber,
+				Field:      column.Field,
+				Value:      value,
+				Reason:     "含有無法以 Big5 表示的字元，CSV 中已以 ? 取代",
+			})
+		}
+		values[i] = encodable
+	}
+	return issues, e.writer.Write(values)
+}
+
+// encodable 將無法以 Big5 表示的字元以 ? 取代，回傳取代後的內容與是否有取代（UTF-8 時原樣回傳）
+func (e *csvEncoder) encodable(value string) (string, bool) {
+	if e.runes == nil || isASCII(value) {
+		return value, false
+	}
+	var builder strings.Builder
+	replaced := false
+	for _, r := range value {
+		if r >= utf8.RuneSelf {
+			if _, err := e.runes.String(string(r)); err != nil {
+				builder.WriteByte('?')
+				replaced = true
+				continue
+			}
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String(), replaced
+}
+
+// close 寫出緩衝的內容
+func (e *csvEncoder) close() error {
+	e.writer.Flush()
+	if err := e.writer.Error(); err != nil {
+		return err
+	}
+	if e.big5 != nil {
+		return e.big5.Close()
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+func TestExportProfileProvenance(t *testing.T) {
+	profile := &ColumnProfile{Name: "自訂", Columns: []ExportColumn{{Field: "FormatCode"}, {Field: "SourceFileName"}}}
+
+	if got := exportProfile(nil, false); got != DefaultColumnProfile {
+		t.Errorf("未指定欄位設定時應為預設欄位設定")
+	}
+	if got := exportProfile(profile, false); got != profile {
+		t.Errorf("不附加來源欄位時應完全依欄位設定")
+	}
+
+	got := exportProfile(profile, true)
+	fields := make([]string, 0, len(got.Columns))
+	for _, column := range got.Columns {
+		fields = append(fields, column.Field)
+	}
+	if want := "FormatCode,SourceFileName,LineNumber"; strings.Join(fields, ",") != want {
+		t.Errorf("附加來源欄位後 = %s，應為 %s", strings.Join(fields, ","), want)
+	}
+	if len(profile.Columns) != 2 {
+		t.Errorf("不應修改原欄位設定")
+	}
+}
+
+func TestExportersIncludeProvenanceByDefault(t *testing.T) {
+	txtFile := writeTestTxt(t, t.TempDir(), "進項.txt", purchaseRecord("21", "1", "", "1000", "50"))
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+	allocation := [][]*TxtFileInfo{fileInfoList}
+	profile := &ColumnProfile{Name: "自訂", Columns: []ExportColumn{{Field: "FormatCode"}}}
+
+	cases := []struct {
+		name     string
+		exporter Exporter
+		ext      string
+		want     string
+	}{
+		{name: "CSV", exporter: &CSVExporter{Profile: profile, Encoding: EncodingUTF8}, ext: ".csv", want: "格式代號,來源檔案,行號\r\n21,進項.txt,1\r\n"},
+		{name: "CSV 省略來源欄位", exporter: &CSVExporter{Profile: profile, Encoding: EncodingUTF8, OmitProvenance: true}, ext: ".csv", want: "格式代號\r\n21\r\n"},
+		{name: "JSON Lines", exporter: &JSONLinesExporter{Profile: profile}, ext: ".jsonl", want: `{"FormatCode":"21","SourceFileName":"進項.txt","LineNumber":1}` + "\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputFolder := t.TempDir()
+			if _, err := tc.exporter.Export(ctx, allocation, outputFolder); err != nil {
+				t.Fatal(err)
+			}
+			matches, err := filepath.Glob(filepath.Join(outputFolder, "*"+tc.ext))
+			if err != nil || len(matches) != 1 {
+				t.Fatalf("產出 %d 個檔案（%v），應為 1 個", len(matches), err)
+			}
+			data, err := os.ReadFile(matches[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.want {
+				t.Errorf("內容 = %q，應為 %q", data, tc.want)
+			}
+		})
+	}
+}
+
+func TestCSVEncoderBig5ReplacesUnsupportedRunes(t *testing.T) {
+	record, err := ParseLine(roundTripCases[0].line, 3, "한글_進項.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile := &ColumnProfile{Columns: []ExportColumn{{Field: "FormatCode"}, {Field: "SourceFileName"}}}
+
+	var buffer bytes.Buffer
+	encoder := newCSVEncoder(&buffer, profile, EncodingBig5)
+	if err := encoder.writeHeader(); err != nil {
+		t.Fatal(err)
+	}
+	issues, err := encoder.writeRecord(record)
+	if err != nil {
+		t.Fatalf("無法以 Big5 表示的字元不應中止匯出: %v", err)
+	}
+	if err := encoder.close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(issues) != 1 || issues[0].Field != "SourceFileName" || issues[0].LineNumber != 3 {
+		t.Fatalf("問題 = %+v，應為來源檔案欄位的一個問題", issues)
+	}
+	decoded, err := traditionalchinese.Big5.NewDecoder().String(buffer.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "格式代號,來源檔案\r\n21,??_進項.txt\r\n"; decoded != want {
+		t.Errorf("CSV 內容 = %q，應為 %q", decoded, want)
+	}
+}
@@ -0,0 +1,459 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strings"
+)
+
+// DuplicateKind 重複發票類別
+type DuplicateKind int
+
+const (
+	// DuplicateExact 內容完全相同（流水號除外），通常是同一份資料匯出兩次
+	DuplicateExact DuplicateKind = iota
+	// DuplicateSameAmount 金額相同但其他欄位不同（例如資料所屬年月），仍會重複計入稅額
+	DuplicateSameAmount
+	// DuplicateNear 金額或稅額不同，需人工確認哪一筆正確
+	DuplicateNear
+)
+
+// String 重複類別名稱
+func (k DuplicateKind) String() string {
+	switch k {
+	case DuplicateExact:
+		return "內容完全相同"
+	case DuplicateSameAmount:
+		return "金額相同、其他欄位不同"
+	case DuplicateNear:
+		return "金額不同"
+	default:
+		return "未知"
+	}
+}
+
+// DuplicateRecord 重複發票中的一筆資料
+type DuplicateRecord struct {
+	// FileName 來源檔案名稱
+	FileName string
+
+	// LineNumber 行號
+	LineNumber int
+
+	// SalesAmount 銷售金額
+	SalesAmount int64
+
+	// TaxAmount 營業稅額
+	TaxAmount int64
+
+	// Copy 與同組較前面的某一筆內容完全相同（流水號除外），可刪除
+	Copy bool
+
+	// Dropped 已由 DropExactDuplicates 刪除
+	Dropped bool
+
+	// file 資料所在的檔案（刪除時改寫其暫存檔）
+	file *TxtFileInfo
+
+	// fingerprint 內容雜湊（流水號除外）
+	fingerprint uint64
+}
+
+// DuplicateGroup 同一張發票（進銷項別 + 字軌號碼 + 銷售人統一編號）的所有資料，依讀取順序排列
+type DuplicateGroup struct {
+	// InvoiceNumber 發票號碼（字軌 + 號碼）
+	InvoiceNumber string
+
+	// SellerTaxId 銷售人統一編號
+	SellerTaxId string
+
+	// Kind 重複類別
+	Kind DuplicateKind
+
+	// Records 重複的資料（至少兩筆）
+	Records []*DuplicateRecord
+}
+
+// DuplicateReport 重複發票檢查結果
+type DuplicateReport struct {
+	// Groups 重複的發票，依第一次出現的順序排列
+	Groups []*DuplicateGroup
+}
+
+// HasDuplicates 是否有重複發票
+func (r *DuplicateReport) HasDuplicates() bool {
+	return r != nil && len(r.Groups) > 0
+}
+
+// Count 各重複類別的組數
+func (r *DuplicateReport) Count(kind DuplicateKind) int {
+	count := 0
+	for _, group := range r.Groups {
+		if group.Kind == kind {
+			count++
+		}
+	}
+	return count
+}
+
+// CopyCount 可刪除的完全重複資料筆數（每組保留第一筆）
+func (r *DuplicateReport) CopyCount() int {
+	count := 0
+	for _, group := range r.Groups {
+		for _, record := range group.Records {
+			if record.Copy && !record.Dropped {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Issues 將每筆未刪除的重複資料轉為驗證問題
+func (r *DuplicateReport) Issues() []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	for _, group := range r.Groups {
+		remaining := group.remaining()
+		if len(remaining) < 2 {
+			continue
+		}
+		for _, record := range remaining {
+			issues = append(issues, group.issue(record.FileName, record.LineNumber))
+		}
+	}
+	return issues
+}
+
+// remaining 尚未刪除的資料
+func (g *DuplicateGroup) remaining() []*DuplicateRecord {
+	records := make([]*DuplicateRecord, 0, len(g.Records))
+	for _, record := range g.Records {
+		if !record.Dropped {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// droppedLines 已由 DropExactDuplicates 刪除的資料（檔案 → 行號）
+func (r *DuplicateReport) droppedLines() map[*TxtFileInfo]map[int]bool {
+	dropped := make(map[*TxtFileInfo]map[int]bool)
+	for _, group := range r.Groups {
+		for _, record := range group.Records {
+			if !record.Dropped {
+				continue
+			}
+			if dropped[record.file] == nil {
+				dropped[record.file] = make(map[int]bool)
+			}
+			dropped[record.file][record.LineNumber] = true
+		}
+	}
+	return dropped
+}
+
+// String 重複發票描述，例如「AB12345678（銷售人 12345675，金額不同）：a.txt 第 3 行、b.txt 第 9 行」
+func (g *DuplicateGroup) String() string {
+	locations := make([]string, 0, len(g.Records))
+	for _, record := range g.Records {
+		location := fmt.Sprintf("%s 第 %d 行", record.FileName, record.LineNumber)
+		if record.Dropped {
+			location += "（已刪除）"
+		}
+		locations = append(locations, location)
+	}
+	return fmt.Sprintf("%s（銷售人 %s，%s）：%s", g.InvoiceNumber, g.SellerTaxId, g.Kind, strings.Join(locations, "、"))
+}
+
+// maxDuplicateLocations 驗證問題說明中最多列出的其他重複位置
+const maxDuplicateLocations = 5
+
+// issue 指定資料的重複發票驗證問題，說明中列出同組其他未刪除的資料位置
+func (g *DuplicateGroup) issue(fileName string, lineNumber int) ValidationIssue {
+	others := make([]string, 0, len(g.Records))
+	for _, record := range g.Records {
+		if record.Dropped || (record.FileName == fileName && record.LineNumber == lineNumber) {
+			continue
+		}
+		others = append(others, fmt.Sprintf("%s 第 %d 行", record.FileName, record.LineNumber))
+	}
+	if len(others) > maxDuplicateLocations {
+		others = append(others[:maxDuplicateLocations], fmt.Sprintf("等 %d 處", len(others)))
+	}
+	return ValidationIssue{
+		FileName:   fileName,
+		LineNumber: lineNumber,
+		Field:      "InvoiceStartNumber",
+		Value:      g.InvoiceNumber,
+		Reason:     fmt.Sprintf("重複發票（%s），另見 %s", g.Kind, strings.Join(others, "、")),
+	}
+}
+
+// duplicateKey 重複發票的比對鍵：進銷項別 + 字軌號碼 + 銷售人統一編號
+// 只比對統一發票；退回及折讓證明單可能多筆對應同一張發票，空白未使用為號碼範圍（由字軌檢查比對），皆不列入比對
+func duplicateKey(record *TaxRecord) (string, bool) {
+	if record.Kind != KindInvoice || record.InvoiceStartNumber == "" || record.IsBlank() || record.IsAllowance() {
+		return "", false
+	}
+	direction := "O"
+	if record.IsInput() {
+		direction = "I"
+	}
+	return direction + record.InvoicePrefix + record.InvoiceStartNumber + record.SellerTaxId, true
+}
+
+// recordFingerprint 資料內容雜湊（略過位置 12-18 的流水號，合併不同 ERP 匯出時流水號通常不同）
+func recordFingerprint(record *TaxRecord) uint64 {
+	data := toSpecBytes(record.RawData)
+	hash := fnv.New64a()
+	if len(data) > 18 {
+		hash.Write(data[:11])
+		hash.Write(data[18:])
+	} else {
+		hash.Write(data)
+	}
+	return hash.Sum64()
+}
+
+// FindDuplicates 跨檔比對重複發票，並在各檔案記錄重複的資料列（匯出 Excel 時標示整列）
+// 依清單順序讀取，每組的第一筆視為原始資料；須在分配（拆分片段）之前呼叫
+// 同時需要彙總登錄範圍檢查時改用 CheckFiles，兩項檢查共用同一次讀取
+func FindDuplicates(ctx context.Context, fileInfoList []*TxtFileInfo) (*DuplicateReport, error) {
+	finder := newDuplicateFinder(fileInfoList)
+	if err := scanFiles(ctx, fileInfoList, finder.add); err != nil {
+		return nil, err
+	}
+	return finder.finish(), nil
+}
+
+// seenInvoice 每張發票第一次出現的位置（多數發票不重複，只保留比對所需的最少資料）
+type seenInvoice struct {
+	file        *TxtFileInfo
+	lineNumber  int
+	salesAmount int64
+	taxAmount   int64
+	fingerprint uint64
+	group       *DuplicateGroup
+}
+
+// duplicateFinder 逐筆比對重複發票，全部讀取後以 finish 取得結果
+type duplicateFinder struct {
+	seen   map[string]*seenInvoice
+	report *DuplicateReport
+}
+
+// newDuplicateFinder 建立 duplicateFinder，並清除各檔案先前記錄的重複資料列
+func newDuplicateFinder(fileInfoList []*TxtFileInfo) *duplicateFinder {
+	for _, fileInfo := range fileInfoList {
+		fileInfo.duplicates = nil
+	}
+	return &duplicateFinder{
+		seen:   make(map[string]*seenInvoice),
+		report: &DuplicateReport{},
+	}
+}
+
+// add 比對一筆資料，第二次出現的發票建立重複群組
+func (d *duplicateFinder) add(fileInfo *TxtFileInfo, record *TaxRecord) {
+	key, ok := duplicateKey(record)
+	if !ok {
+		return
+	}
+
+	current := &DuplicateRecord{
+		FileName:    record.SourceFileName,
+		LineNumber:  record.LineNumber,
+		SalesAmount: parseAmountToInt(record.SalesAmount),
+		TaxAmount:   parseAmountToInt(record.TaxAmount),
+		file:        fileInfo,
+		fingerprint: recordFingerprint(record),
+	}
+	first, ok := d.seen[key]
+	if !ok {
+		d.seen[key] = &seenInvoice{
+			file:        fileInfo,
+			lineNumber:  current.LineNumber,
+			salesAmount: current.SalesAmount,
+			taxAmount:   current.TaxAmount,
+			fingerprint: current.fingerprint,
+		}
+		return
+	}
+
+	// 第二次出現時才建立群組
+	if first.group == nil {
+		first.group = &DuplicateGroup{
+			InvoiceNumber: record.InvoiceNumber(),
+			SellerTaxId:   record.SellerTaxId,
+			Records: []*DuplicateRecord{{
+				FileName:    first.file.FileName,
+				LineNumber:  first.lineNumber,
+				SalesAmount: first.salesAmount,
+				TaxAmount:   first.taxAmount,
+				file:        first.file,
+				fingerprint: first.fingerprint,
+			}},
+		}
+		d.report.Groups = append(d.report.Groups, first.group)
+		first.file.markDuplicate(first.lineNumber, first.group)
+	}
+	for _, earlier := range first.group.Records {
+		if earlier.fingerprint == current.fingerprint {
+			current.Copy = true
+			break
+		}
+	}
+	first.group.Records = append(first.group.Records, current)
+	fileInfo.markDuplicate(current.LineNumber, first.group)
+}
+
+// finish 判定各組的重複類別並回傳結果（釋放比對用的資料）
+func (d *duplicateFinder) finish() *DuplicateReport {
+	d.seen = nil
+	for _, group := range d.report.Groups {
+		group.Kind = group.classify()
+	}
+	return d.report
+}
+
+// classify 依組內資料判定重複類別
+func (g *DuplicateGroup) classify() DuplicateKind {
+	first := g.Records[0]
+	kind := DuplicateExact
+	for _, record := range g.Records[1:] {
+		if record.SalesAmount != first.SalesAmount || record.TaxAmount != first.TaxAmount {
+			return DuplicateNear
+		}
+		if record.fingerprint != first.fingerprint {
+			kind = DuplicateSameAmount
+		}
+	}
+	return kind
+}
+
+// markDuplicate 記錄重複的資料列（片段與原始檔共用記錄）
+func (info *TxtFileInfo) markDuplicate(lineNumber int, group *DuplicateGroup) {
+	if info.duplicates == nil {
+		info.duplicates = make(map[int]*DuplicateGroup)
+	}
+	info.duplicates[lineNumber] = group
+}
+
+// duplicateGroup 指定行號所屬的重複發票（沒有重複時回傳 nil）
+func (info *TxtFileInfo) duplicateGroup(lineNumber int) *DuplicateGroup {
+	if info.source != nil {
+		return info.source.duplicateGroup(lineNumber)
+	}
+	return info.duplicates[lineNumber]
+}
+
+// DropExactDuplicates 刪除完全重複的資料（流水號除外內容相同，每組保留第一筆），改寫暫存檔並更新有效資料筆數
+// 刪除後只剩一筆的群組不再標示；須在分配（拆分片段）之前呼叫，回傳刪除筆數
+func DropExactDuplicates(ctx context.Context, report *DuplicateReport) (int, error) {
+	dropLines := make(map[*TxtFileInfo]map[int]bool)
+	order := make([]*TxtFileInfo, 0)
+	for _, group := range report.Groups {
+		for _, record := range group.Records {
+			if !record.Copy || record.Dropped {
+				continue
+			}
+			if dropLines[record.file] == nil {
+				dropLines[record.file] = make(map[int]bool)
+				order = append(order, record.file)
+			}
+			dropLines[record.file][record.LineNumber] = true
+		}
+	}
+
+	dropped := 0
+	for _, fileInfo := range order {
+		if err := fileInfo.dropLines(ctx, dropLines[fileInfo]); err != nil {
+			return dropped, fmt.Errorf("檔案 %s 刪除重複資料失敗: %v", fileInfo.FileName, err)
+		}
+		dropped += len(dropLines[fileInfo])
+	}
+
+	for _, group := range report.Groups {
+		for _, record := range group.Records {
+			if record.Copy && !record.Dropped {
+				record.Dropped = true
+				delete(record.file.duplicates, record.LineNumber)
+			}
+		}
+		if remaining := group.remaining(); len(remaining) == 1 {
+			delete(remaining[0].file.duplicates, remaining[0].LineNumber)
+		}
+	}
+	return dropped, nil
+}
+
+// dropLines 改寫暫存檔，移除指定行號的資料並更新有效資料筆數、行號範圍與分析時記錄的筆數金額
+func (info *TxtFileInfo) dropLines(ctx context.Context, lineNumbers map[int]bool) error {
+	input, err := os.Open(info.SpillPath)
+	if err != nil {
+		return fmt.Errorf("讀取暫存檔失敗: %v", err)
+	}
+	defer input.Close()
+
+	output, err := os.CreateTemp("", "accountingTools_*.spill")
+	if err != nil {
+		return fmt.Errorf("建立暫存檔失敗: %v", err)
+	}
+
+	writer := bufio.NewWriter(output)
+	recordCount, startLine, endLine := 0, 0, 0
+	analyzed := info.analyzed
+	copyErr := func() error {
+		scanner := bufio.NewScanner(input)
+		for scanner.Scan() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			lineNumber, line, err := splitSpillLine(scanner.Text())
+			if err != nil {
+				return err
+			}
+			if lineNumbers[lineNumber] {
+				record, err := ParseLine(line, lineNumber, info.FileName)
+				if err != nil {
+					return err
+				}
+				analyzed.remove(record)
+				continue
+			}
+			if _, err := writer.WriteString(scanner.Text() + "\n"); err != nil {
+				return err
+			}
+			if recordCount == 0 {
+				startLine = lineNumber
+			}
+			endLine = lineNumber
+			recordCount++
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}()
+
+	closeErr := output.Close()
+	if copyErr == nil {
+		copyErr = closeErr
+	}
+	if copyErr != nil {
+		os.Remove(output.Name())
+		return copyErr
+	}
+
+	os.Remove(info.SpillPath)
+	info.SpillPath = output.Name()
+	info.RecordCount = recordCount
+	info.analyzed = analyzed
+	if info.GroupBy != GroupByNone {
+		info.StartLine, info.EndLine = startLine, endLine
+	}
+	return nil
+}
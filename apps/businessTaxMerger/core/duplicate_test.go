@@ -0,0 +1,183 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// duplicateLocation 重複資料的位置與是否可刪除
+type duplicateLocation struct {
+	location string
+	copy     bool
+}
+
+// duplicateFixtures 兩個 TXT 測試檔：b.txt 與 a.txt 重複的發票分別為內容完全相同（流水號不同）、金額相同、金額不同
+func duplicateFixtures(t *testing.T) []*TxtFileInfo {
+	t.Helper()
+	invoice := func(number int, amount, tax string, sequence int) TaxRecord {
+		record := salesRecord("31", "1", amount, tax)
+		record.InvoiceStartNumber = fmt.Sprintf("%08d", number)
+		record.SequenceNumber = fmt.Sprintf("%07d", sequence)
+		return record
+	}
+	otherMonth := invoice(2, "2000", "100", 12)
+	otherMonth.DataMonth = "02"
+	// 進項與銷項分開比對
+	purchase := purchaseRecord("21", "1", "", "1000", "50")
+	purchase.InvoicePrefix = "AB"
+	// 退回及折讓證明單不比對
+	allowance := invoice(3, "3000", "150", 16)
+	allowance.FormatCode = "33"
+
+	dir := t.TempDir()
+	a := writeTestTxt(t, dir, "a.txt",
+		invoice(1, "1000", "50", 1),
+		invoice(2, "2000", "100", 2),
+		invoice(3, "3000", "150", 3),
+		invoice(4, "4000", "200", 4),
+	)
+	b := writeTestTxt(t, dir, "b.txt",
+		invoice(1, "1000", "50", 11),
+		otherMonth,
+		invoice(2, "2000", "100", 13),
+		invoice(3, "3100", "155", 14),
+		purchase,
+		allowance,
+	)
+
+	fileInfoList, err := AnalyzeFiles(context.Background(), []string{a, b}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ReleaseFiles(fileInfoList) })
+	return fileInfoList
+}
+
+func TestFindDuplicatesClassification(t *testing.T) {
+	fileInfoList := duplicateFixtures(t)
+	report, err := FindDuplicates(context.Background(), fileInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		invoiceNumber string
+		kind          DuplicateKind
+		records       []duplicateLocation
+	}{
+		{
+			invoiceNumber: "AB00000001",
+			kind:          DuplicateExact,
+			records:       []duplicateLocation{{"a.txt:1", false}, {"b.txt:1", true}},
+		},
+		{
+			invoiceNumber: "AB00000002",
+			kind:          DuplicateSameAmount,
+			records:       []duplicateLocation{{"a.txt:2", false}, {"b.txt:2", false}, {"b.txt:3", true}},
+		},
+		{
+			invoiceNumber: "AB00000003",
+			kind:          DuplicateNear,
+			records:       []duplicateLocation{{"a.txt:3", false}, {"b.txt:4", false}},
+		},
+	}
+	if len(report.Groups) != len(want) {
+		t.Fatalf("共 %d 組重複發票，應為 %d 組：%v", len(report.Groups), len(want), report.Groups)
+	}
+	for i, w := range want {
+		group := report.Groups[i]
+		if group.InvoiceNumber != w.invoiceNumber || group.Kind != w.kind {
+			t.Errorf("第 %d 組 = %s（%s），應為 %s（%s）", i+1, group.InvoiceNumber, group.Kind, w.invoiceNumber, w.kind)
+		}
+		records := make([]duplicateLocation, 0, len(group.Records))
+		for _, record := range group.Records {
+			records = append(records, duplicateLocation{fmt.Sprintf("%s:%d", record.FileName, record.LineNumber), record.Copy})
+		}
+		if !reflect.DeepEqual(records, w.records) {
+			t.Errorf("%s 的資料 = %v，應為 %v", group.InvoiceNumber, records, w.records)
+		}
+	}
+
+	for kind, count := range map[DuplicateKind]int{DuplicateExact: 1, DuplicateSameAmount: 1, DuplicateNear: 1} {
+		if got := report.Count(kind); got != count {
+			t.Errorf("%s 共 %d 組，應為 %d 組", kind, got, count)
+		}
+	}
+	if got := report.CopyCount(); got != 2 {
+		t.Errorf("可刪除 %d 筆，應為 2 筆", got)
+	}
+	if got := len(report.Issues()); got != 7 {
+		t.Errorf("驗證問題 %d 個，應為 7 個（每筆重複資料一個）", got)
+	}
+}
+
+func TestDropExactDuplicates(t *testing.T) {
+	ctx := context.Background()
+	fileInfoList := duplicateFixtures(t)
+	a, b := fileInfoList[0], fileInfoList[1]
+	report, err := FindDuplicates(ctx, fileInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dropped, err := DropExactDuplicates(ctx, report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 2 {
+		t.Fatalf("刪除 %d 筆，應為 2 筆", dropped)
+	}
+
+	// 暫存檔改寫後只剩未刪除的資料，行號維持原始檔的行號
+	lines := func(info *TxtFileInfo) []int {
+		lineNumbers := make([]int, 0)
+		if err := info.EachRecord(func(record *TaxRecord) error {
+			lineNumbers = append(lineNumbers, record.LineNumber)
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return lineNumbers
+	}
+	if got, want := lines(a), []int{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a.txt 的資料行 = %v，應為 %v", got, want)
+	}
+	if got, want := lines(b), []int{2, 4, 5, 6}; !reflect.DeepEqual(got, want) {
+		t.Errorf("b.txt 的資料行 = %v，應為 %v", got, want)
+	}
+	if a.RecordCount != 4 || b.RecordCount != 4 {
+		t.Errorf("有效資料 a.txt %d 筆、b.txt %d 筆，應皆為 4 筆", a.RecordCount, b.RecordCount)
+	}
+
+	// 只剩一筆的群組不再標示，仍有兩筆以上的群組維持標示
+	marked := []struct {
+		info       *TxtFileInfo
+		lineNumber int
+		want       bool
+	}{
+		{a, 1, false},
+		{b, 1, false},
+		{a, 2, true},
+		{b, 2, true},
+		{b, 3, false},
+		{a, 3, true},
+		{b, 4, true},
+	}
+	for _, m := range marked {
+		if got := m.info.duplicateGroup(m.lineNumber) != nil; got != m.want {
+			t.Errorf("%s 第 %d 行標示為重複 = %v，應為 %v", m.info.FileName, m.lineNumber, got, m.want)
+		}
+	}
+	if got := len(report.Issues()); got != 4 {
+		t.Errorf("驗證問題 %d 個，應為 4 個", got)
+	}
+	if got := report.CopyCount(); got != 0 {
+		t.Errorf("刪除後可刪除 %d 筆，應為 0 筆", got)
+	}
+	if description := report.Groups[0].String(); !strings.Contains(description, "b.txt 第 1 行（已刪除）") {
+		t.Errorf("重複發票描述 = %q，應標示已刪除", description)
+	}
+}
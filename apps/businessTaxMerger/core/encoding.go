@@ -0,0 +1,191 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// FileEncoding 檔案編碼
+type FileEncoding int
+
+const (
+	// EncodingUTF8 UTF-8（無 BOM，純 ASCII 檔案亦歸於此類）
+	EncodingUTF8 FileEncoding = iota
+	// EncodingUTF8BOM UTF-8 with BOM
+	EncodingUTF8BOM
+	// EncodingBig5 Big5 / CP950
+	EncodingBig5
+)
+
+// String 編碼名稱
+func (e FileEncoding) String() string {
+	switch e {
+	case EncodingUTF8BOM:
+		return "UTF-8 (BOM)"
+	case EncodingBig5:
+		return "Big5"
+	default:
+		return "UTF-8"
+	}
+}
+
+// encodingSampleSize 偵測編碼時讀取的位元組數
+const encodingSampleSize = 64 * 1024
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectEncoding 依檔案開頭內容判斷編碼
+// 有 BOM 為 UTF-8 (BOM)；內容為合法 UTF-8 為 UTF-8；其餘視為 Big5
+func detectEncoding(sample []byte) FileEncoding {
+	if bytes.HasPrefix(sample, utf8BOM) {
+		return EncodingUTF8BOM
+	}
+
+	// 取樣可能剛好切在多位元組字元中間，去掉結尾不完整的字元再判斷
+	for i := len(sample) - 1; i >= 0 && i >= len(sample)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(sample[i]) {
+			if !utf8.FullRune(sample[i:]) {
+				sample = sample[:i]
+			}
+			break
+		}
+	}
+
+	if utf8.Valid(sample) {
+		return EncodingUTF8
+	}
+	return EncodingBig5
+}
+
+// txtReader 逐行轉為 UTF-8 的 TXT 檔案讀取器
+// 取樣判斷為 UTF-8 的檔案遇到不合法的 UTF-8 資料行時改以 Big5 解碼（Big5 檔案的開頭可能全為 ASCII，取樣無法判斷），
+// 此後整個檔案皆視為 Big5，Encoding 隨之更新
+type txtReader struct {
+	scanner  *bufio.Scanner
+	file     *os.File
+	decoder  *encoding.Decoder
+	line     string
+	err      error
+	Encoding FileEncoding
+}
+
+// Scan 讀取下一行，沒有資料或發生錯誤時回傳 false
+func (r *txtReader) Scan() bool {
+	if r.err != nil || !r.scanner.Scan() {
+		return false
+	}
+	data := r.scanner.Bytes()
+	if r.Encoding == EncodingUTF8 && !utf8.Valid(data) {
+		r.Encoding = EncodingBig5
+	}
+	if r.Encoding != EncodingBig5 || isASCII(string(data)) {
+		r.line = string(data)
+		return true
+	}
+	decoded, err := r.decoder.Bytes(data)
+	if err != nil {
+		r.err = err
+		return false
+	}
+	r.line = string(decoded)
+	return true
+}
+
+// Text 目前這一行（已轉為 UTF-8，不含換行字元）
+func (r *txtReader) Text() string {
+	return r.line
+}
+
+// Err 讀取或解碼時發生的錯誤
+func (r *txtReader) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.scanner.Err()
+}
+
+// Close 關閉檔案
+func (r *txtReader) Close() error {
+	return r.file.Close()
+}
+
+// openTxtFile 開啟 TXT 檔案，依開頭內容偵測編碼後逐行轉為 UTF-8 讀取
+func openTxtFile(filePath string) (*txtReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReaderSize(file, encodingSampleSize)
+	sample, err := buffered.Peek(encodingSampleSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		file.Close()
+		return nil, err
+	}
+
+	reader := &txtReader{
+		file:     file,
+		decoder:  traditionalchinese.Big5.NewDecoder(),
+		Encoding: detectEncoding(sample),
+	}
+	if reader.Encoding == EncodingUTF8BOM {
+		if _, err := buffered.Discard(len(utf8BOM)); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	reader.scanner = bufio.NewScanner(buffered)
+	return reader, nil
+}
+
+// toSpecBytes 將一行資料轉為媒體檔規格的位元組表示（Big5/CP950）
+// 規格的欄位位置以位元組計算，全形字佔 2 個位元組；無法以 Big5 表示的字元也以 2 個位元組佔位
+func toSpecBytes(line string) []byte {
+	if isASCII(line) {
+		return []byte(line)
+	}
+
+	encoder := traditionalchinese.Big5.NewEncoder()
+	data := make([]byte, 0, len(line))
+	for _, r := range line {
+		if r < utf8.RuneSelf {
+			data = append(data, byte(r))
+			continue
+		}
+		encoded, err := encoder.Bytes([]byte(string(r)))
+		if err != nil || len(encoded) == 0 {
+			data = append(data, '?', '?')
+			continue
+		}
+		data = append(data, encoded...)
+	}
+	return data
+}
+
+// fromSpecBytes 將 Big5 位元組轉回 UTF-8 字串
+func fromSpecBytes(data []byte) string {
+	if isASCII(string(data)) {
+		return string(data)
+	}
+	decoded, err := traditionalchinese.Big5.NewDecoder().Bytes(data)
+	if err != nil {
+		return string(data)
+	}
+	return string(decoded)
+}
+
+// isASCII 是否全為 ASCII 字元
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/traditionalchinese"
+)
+
+// big5Bytes 將 UTF-8 字串轉為 Big5 位元組
+func big5Bytes(t *testing.T, s string) []byte {
+	t.Helper()
+	data, err := traditionalchinese.Big5.NewEncoder().Bytes([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDetectEncoding(t *testing.T) {
+	cases := []struct {
+		name   string
+		sample []byte
+		want   FileEncoding
+	}{
+		{name: "BOM", sample: append(append([]byte{}, utf8BOM...), "31備註"...), want: EncodingUTF8BOM},
+		{name: "純 ASCII", sample: []byte("31123456789"), want: EncodingUTF8},
+		{name: "UTF-8 中文", sample: []byte("31備註"), want: EncodingUTF8},
+		{name: "Big5 中文", sample: append([]byte("31"), big5Bytes(t, "備註")...), want: EncodingBig5},
+		{name: "取樣切在 UTF-8 字元中間", sample: []byte("31備註")[:7], want: EncodingUTF8},
+		{name: "空白檔案", sample: nil, want: EncodingUTF8},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := detectEncoding(tc.sample); got != tc.want {
+				t.Errorf("detectEncoding = %s，應為 %s", got, tc.want)
+			}
+		})
+	}
+}
+
+// big5RemarkRecord 備註欄（位置 74-78）含全形字的銷項發票，Big5 每字 2 位元組
+func big5RemarkRecord() TaxRecord {
+	record := salesRecord("31", "1", "1000", "50")
+	record.Blank3 = "備註"
+	record.AggregationMark = "A"
+	return record
+}
+
+func TestParseLineSlicesBig5Bytes(t *testing.T) {
+	line := testLine(t, big5RemarkRecord())
+	if length := len([]rune(line)); length != RecordLength-2 {
+		t.Fatalf("範例長度 %d 個字元，應為 %d（兩個全形字各佔 2 位元組）", length, RecordLength-2)
+	}
+	record, err := ParseLine(line, 1, "big5.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 全形字之後的欄位依位元組位置截取，不會位移
+	if strings.TrimSpace(record.Blank3) != "備註" || record.SpecialTaxRate != "" || record.AggregationMark != "A" {
+		t.Errorf("備註 %q、特種稅額稅率 %q、彙加註記 %q，應為 \"備註\"、\"\"、\"A\"", record.Blank3, record.SpecialTaxRate, record.AggregationMark)
+	}
+}
+
+func TestAnalyzeFileDetectsBig5AfterSample(t *testing.T) {
+	// 開頭超過取樣大小的資料全為 ASCII，之後才出現 Big5 字元
+	ascii := testLine(t, salesRecord("31", "1", "1000", "50")) + "\r\n"
+	count := encodingSampleSize/len(ascii) + 1
+	data := []byte(strings.Repeat(ascii, count))
+	data = append(data, toSpecBytes(testLine(t, big5RemarkRecord()))...)
+	data = append(data, "\r\n"...)
+	txtFile := filepath.Join(t.TempDir(), "big5.txt")
+	if err := os.WriteFile(txtFile, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fileInfoList, err := AnalyzeFiles(context.Background(), []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+	info := fileInfoList[0]
+
+	if info.Encoding != EncodingBig5 {
+		t.Errorf("編碼 = %s，應為 %s", info.Encoding, EncodingBig5)
+	}
+	if len(info.ParseErrors) != 0 || info.RecordCount != count+1 {
+		t.Fatalf("有效資料 %d 筆、結構錯誤 %v，應為 %d 筆、沒有結構錯誤", info.RecordCount, info.ParseErrors, count+1)
+	}
+	var last *TaxRecord
+	if err := info.EachRecord(func(record *TaxRecord) error {
+		last = record
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(last.Blank3) != "備註" || last.AggregationMark != "A" {
+		t.Errorf("最後一筆備註 %q、彙加註記 %q，應為 \"備註\"、\"A\"", last.Blank3, last.AggregationMark)
+	}
+}
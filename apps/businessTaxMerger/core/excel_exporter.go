@@ -1,155 +1,302 @@
 package core
 
 import (
-	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/xuri/excelize/v2"
 )
 
-// ExportToExcel 匯出營業稅資料到 Excel
-func ExportToExcel(allocation [][]*TxtFileInfo, outputFolder string, maxRowsPerExcel int) error {
+// dataSheetName 資料工作表名稱
+const dataSheetName = "營業人進銷項資料"
+
+// MaxExcelDataRows 單一工作表最多可寫入的資料筆數（Excel 列數上限扣除標題列）
+const MaxExcelDataRows = excelize.TotalRows - 1
+
+// excelDataRows 每個工作表實際可寫入的資料筆數：maxRowsPerExcel <= 0 或超過 Excel 上限時以上限為準
+func excelDataRows(maxRowsPerExcel int) int {
+	if maxRowsPerExcel <= 0 || maxRowsPerExcel > MaxExcelDataRows {
+		return MaxExcelDataRows
+	}
+	return maxRowsPerExcel
+}
+
+// ExportToExcel 匯出營業稅資料到 Excel，並回傳所有檔案的驗證報告
+// 每個 Excel 以 StreamWriter 逐筆寫入，資料不會整批載入記憶體
+// 各 Excel 以 workers 個 worker 並行產出（<= 0 表示使用 DefaultWorkers），檔案編號固定依分配順序
+// ctx 取消或任一檔案失敗時停止產出，寫到一半的檔案會被刪除；profile 為 nil 時使用預設欄位設定
+// maxRowsPerExcel 為每個 Excel 的資料筆數上限（<= 0 或超過 MaxExcelDataRows 時以 MaxExcelDataRows 為準），超過時匯出失敗
+// periods 為 AnalyzePeriods 的結果，nil 時匯出前先讀取一次計算
+func ExportToExcel(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string, maxRowsPerExcel int, workers int, profile *ColumnProfile, options ValidationOptions, periods *PeriodReport) (*ValidationReport, error) {
+	if profile == nil {
+		profile = DefaultColumnProfile
+	}
 	timestamp := time.Now().Format("20060102_150405")
+	workbookReports := make([]*ValidationReport, len(allocation))
 
-	for i, fileGroup := range allocation {
-		fileName := fmt.Sprintf("營業人進銷項資料_%d_%s.xlsx", i+1, timestamp)
+	// 403 試算與字軌檢查須以整期資料計算（同一期別可能分配到多個 Excel）
+	if periods == nil {
+		var err error
+		if periods, err = AnalyzePeriods(ctx, allocation); err != nil {
+			return &ValidationReport{}, err
+		}
+	}
+
+	// 並行時避免各檔案的進度訊息交錯
+	var printMu sync.Mutex
+
+	err := runWorkers(ctx, workers, len(allocation), func(ctx context.Context, i int) error {
+		fileGroup := allocation[i]
+		fileName := allocationFileName(fileGroup, i+1, timestamp, ".xlsx")
 		fullPath := filepath.Join(outputFolder, fileName)
 
-		fmt.Printf("正在產出第 %d 個 Excel 檔案...\n", i+1)
+		printMu.Lock()
+		fmt.Printf("正在產出第 %d 個 Excel 檔案（%d 個 TXT 檔案）...\n", i+1, len(fileGroup))
+		printMu.Unlock()
 
-		// 讀取並解析所有分配到這個 Excel 的檔案
-		allRecords := make([]*TaxRecord, 0)
+		workbookReport, recordCount, err := createExcelFile(ctx, fullPath, fileGroup, i+1, maxRowsPerExcel, profile, options, periods)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("產生 Excel 檔案 %s 失敗: %v", fileName, err)
+		}
+		workbookReports[i] = workbookReport
 
-		fmt.Printf("  讀取並解析 %d 個檔案...\n", len(fileGroup))
+		printMu.Lock()
+		defer printMu.Unlock()
+		fmt.Printf("  ✓ 已產出第 %d 個 Excel 檔案: %s（%d 筆資料）\n", i+1, fileName, recordCount)
 		for _, fileInfo := range fileGroup {
-			records, err := parseFile(fileInfo.FilePath)
-			if err != nil {
-				fmt.Printf("  警告: 解析檔案 %s 失敗: %v\n", fileInfo.FileName, err)
-				continue
+			if fileInfo.IsPart() {
+				fmt.Printf("    含 %s [%s]\n", fileInfo.FileName, fileInfo.PartDescription())
 			}
-			allRecords = append(allRecords, records...)
 		}
+		if workbookReport.HasParseErrors() {
+			fmt.Printf("    ⚠ %d 個結構錯誤的資料行未匯入，請參閱「%s」工作表\n", len(workbookReport.ParseErrors), parseErrorSheetName)
+		}
+		if workbookReport.HasIssues() {
+			fmt.Printf("    ⚠ 發現 %d 個驗證問題，請參閱「%s」工作表\n", len(workbookReport.Issues), validationSheetName)
+		}
+		return nil
+	})
 
-		fmt.Printf("  ✓ 已讀取 %d 筆資料\n", len(allRecords))
-
-		// 產生 Excel
-		fmt.Println("  產生 Excel 檔案...")
-		if err := createExcelFile(fullPath, allRecords, i+1); err != nil {
-			return fmt.Errorf("產生 Excel 檔案失敗: %v", err)
+	// 依檔案編號順序合併報告
+	report := &ValidationReport{}
+	for _, workbookReport := range workbookReports {
+		if workbookReport != nil {
+			report.Merge(workbookReport)
 		}
+	}
+
+	return report, err
+}
 
-		fmt.Printf("  ✓ 已產出: %s\n", fileName)
+// allocationFileName 各分配的輸出檔名，例如 營業人進銷項資料_1_<時間>.xlsx
+// 分組時檔名加上分組值，例如 營業人進銷項資料_123456789_1_<時間>.xlsx
+func allocationFileName(fileGroup []*TxtFileInfo, number int, timestamp string, ext string) string {
+	if len(fileGroup) > 0 && fileGroup[0].GroupBy != GroupByNone {
+		return fmt.Sprintf("營業人進銷項資料_%s_%d_%s%s", groupFileNamePart(fileGroup[0]), number, timestamp, ext)
 	}
+	return fmt.Sprintf("營業人進銷項資料_%d_%s%s", number, timestamp, ext)
+}
 
-	return nil
+// groupFileNamePart 檔名中的分組值（沒有有效資料的組別沒有分組值，英數字以外的字元以 _ 取代）
+func groupFileNamePart(fileInfo *TxtFileInfo) string {
+	value := strings.TrimSpace(fileInfo.GroupValue)
+	if value == "" {
+		return "無有效資料"
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= '0' && r <= '9') || (r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') {
+			return r
+		}
+		return '_'
+	}, value)
 }
 
-// parseFile 解析檔案並返回記錄列表
-func parseFile(filePath string) ([]*TaxRecord, error) {
-	file, err := os.Open(filePath)
+// createExcelFile 建立 Excel 檔案，逐檔讀取暫存資料並以 StreamWriter 寫入
+// periods 為全部分配的 403 試算與字軌檢查，只寫入本檔案有資料的申報營業人與期別
+// 回傳此檔案的驗證報告與寫入筆數；ctx 取消時中止，不會留下寫到一半的檔案
+func createExcelFile(ctx context.Context, filePath string, fileGroup []*TxtFileInfo, fileNumber int, maxRows int, profile *ColumnProfile, options ValidationOptions, periods *PeriodReport) (*ValidationReport, int, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newDataStyles(f)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	defer file.Close()
 
-	records := make([]*TaxRecord, 0)
-	fileName := filepath.Base(filePath)
+	// 資料工作表沿用預設的 Sheet1，放在第一個並於開啟時顯示
+	// 不另外呼叫 SetActiveSheet 或 DeleteSheet：兩者都會把 StreamWriter 寫好的工作表整份讀回記憶體
+	if err := f.SetSheetName("Sheet1", dataSheetName); err != nil {
+		return nil, 0, err
+	}
 
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
+	totals := newControlTotals()
+	report, recordCount, err := writeDataSheet(ctx, f, dataSheetName, fileGroup, maxRows, profile, options, styles, totals)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
+	// 寫入彙總（控制總數）
+	if err := writeSummarySheet(f, totals); err != nil {
+		return nil, 0, err
+	}
 
-		if len(strings.TrimSpace(line)) == 0 {
-			continue
+	// 兼營應稅及免稅時寫入 403 試算（依全部分配的資料計算）
+	if returns := periods.returns403.mixedReturns(totals.periods); len(returns) > 0 {
+		if err := writeReturn403Sheet(f, returns); err != nil {
+			return nil, 0, err
 		}
+	}
 
-		record, err := ParseLine(line, lineNumber, fileName)
-		if err != nil {
-			fmt.Printf("    警告: 檔案 %s 第 %d 行解析失敗: %v\n", fileName, lineNumber, err)
-			continue
+	// 有作廢或空白未使用發票時另列一個工作表
+	if len(totals.voided) > 0 {
+		if err := writeVoidedSheet(f, totals.voided); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	// 有銷項發票時寫入字軌檢查（依全部分配的資料檢查）
+	if tracks := periods.Sequence.forPeriods(totals.periods); len(tracks.Tracks) > 0 {
+		if err := writeSequenceSheet(f, tracks); err != nil {
+			return nil, 0, err
 		}
+	}
 
-		records = append(records, record)
+	// 寫入來源檔案（各來源檔案或片段的行號範圍）
+	if err := writeSourceSheet(f, []string{dataSheetName}, [][]*TxtFileInfo{fileGroup}); err != nil {
+		return nil, 0, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	// 寫入驗證報告
+	if report.HasIssues() {
+		if err := writeValidationSheet(f, report.Issues); err != nil {
+			return nil, 0, err
+		}
 	}
 
-	return records, nil
-}
+	// 寫入錯誤清單
+	if report.HasParseErrors() {
+		if err := writeParseErrorSheet(f, report.ParseErrors); err != nil {
+			return nil, 0, err
+		}
+	}
 
-// createExcelFile 建立 Excel 檔案
-func createExcelFile(filePath string, records []*TaxRecord, fileNumber int) error {
-	f := excelize.NewFile()
-	defer f.Close()
+	// 儲存檔案（儲存前再確認一次是否已取消）
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+	if err := f.SaveAs(filePath); err != nil {
+		// 移除寫到一半的檔案
+		os.Remove(filePath)
+		return nil, 0, err
+	}
 
-	sheetName := "營業人進銷項資料"
+	return report, recordCount, nil
+}
+
+// writeDataSheet 建立資料工作表，逐檔讀取暫存資料並以 StreamWriter 寫入，同時累計控制總數到 totals
+// （各來源另記分析時的筆數與金額，供彙總工作表核對）
+// 資料超過 maxRows 筆（<= 0 或超過 MaxExcelDataRows 時以 MaxExcelDataRows 為準）時回傳錯誤，不會寫出超過 Excel 上限的列
+// 回傳此工作表的驗證報告與寫入筆數
+func writeDataSheet(ctx context.Context, f *excelize.File, sheetName string, fileGroup []*TxtFileInfo, maxRows int, profile *ColumnProfile, options ValidationOptions, styles *dataStyles, totals *controlTotals) (*ValidationReport, int, error) {
+	report := &ValidationReport{}
 
 	// 創建工作表
-	index, err := f.NewSheet(sheetName)
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return nil, 0, err
+	}
+
+	stream, err := f.NewStreamWriter(sheetName)
 	if err != nil {
-		return err
+		return nil, 0, err
 	}
 
 	// 設定標題
-	if err := setupHeaders(f, sheetName); err != nil {
-		return err
+	if err := setupHeaders(f, stream, profile); err != nil {
+		return nil, 0, err
 	}
 
-	// 寫入資料
-	if err := writeData(f, sheetName, records); err != nil {
-		return err
+	// 逐檔逐筆寫入資料
+	maxRows = excelDataRows(maxRows)
+	row := 2 // 從第二列開始（第一列是標題）
+	for _, fileInfo := range fileGroup {
+		source := sourceLabel(fileInfo)
+		total := totals.addSource(source)
+		total.ParseErrors += len(fileInfo.ParseErrors)
+		total.Analyzed.merge(fileInfo.analyzed)
+		err := fileInfo.EachRecord(func(record *TaxRecord) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if row-1 > maxRows {
+				return fmt.Errorf("資料超過每個工作表的最大筆數 %d 筆", maxRows)
+			}
+			issues, err := writeData(stream, styles, row, record, profile, options, fileInfo.crossRecordChecks(record.LineNumber))
+			if err != nil {
+				return err
+			}
+			report.Add(issues...)
+			totals.add(source, record)
+			row++
+			return nil
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("寫入檔案 %s 的資料失敗: %v", fileInfo.FileName, err)
+		}
+		report.AddParseErrors(fileInfo.ParseErrors...)
+	}
+
+	if err := stream.Flush(); err != nil {
+		return nil, 0, err
 	}
 
-	// 設定為預設工作表
-	f.SetActiveSheet(index)
+	return report, row - 2, nil
+}
 
-	// 刪除預設的 Sheet1
-	if err := f.DeleteSheet("Sheet1"); err != nil {
-		// 忽略錯誤，可能 Sheet1 不存在
+// setupHeaders 依欄位設定寫入 Excel 標題列、欄寬與凍結窗格
+// StreamWriter 規定欄寬與窗格須在寫入任何資料列之前設定
+func setupHeaders(f *excelize.File, stream *excelize.StreamWriter, profile *ColumnProfile) error {
+	headerStyle, err := newHeaderStyle(f)
+	if err != nil {
+		return err
 	}
 
-	// 儲存檔案
-	if err := f.SaveAs(filePath); err != nil {
+	// 設定欄寬
+	for i, column := range profile.Columns {
+		if err := stream.SetColWidth(i+1, i+1, column.width()); err != nil {
+			return err
+		}
+	}
+
+	// 凍結第一列
+	if err := stream.SetPanes(&excelize.Panes{
+		Freeze:      true,
+		YSplit:      1,
+		TopLeftCell: "A2",
+		ActivePane:  "bottomLeft",
+	}); err != nil {
 		return err
 	}
 
-	return nil
+	// 寫入標題
+	cells := make([]interface{}, len(profile.Columns))
+	for i, column := range profile.Columns {
+		cells[i] = excelize.Cell{StyleID: headerStyle, Value: column.header()}
+	}
+	return stream.SetRow("A1", cells)
 }
 
-// setupHeaders 設定 Excel 標題列
-func setupHeaders(f *excelize.File, sheetName string) error {
-	// 定義標題（橘色底的欄位）
-	headers := []string{
-		"格式代號",
-		"申報營業人稅籍編號",
-		"流水號",
-		"資料所屬年度",
-		"資料所屬月份",
-		"買受人統一編號",
-		// "發票訖號",
-		"銷售人統一編號",
-		"發票(起)號碼", // 發票字軌 + 發票(起)號碼 合併
-		"銷售金額",
-		// "營業稅稅基",
-		"課稅別",
-		"營業稅額",
-		"扣抵代號",
-		"彙加註記",
-		// "分攤註記",
-	}
-
-	// 創建標題樣式 (橘色背景 + 粗體 + 置中 + 邊框)
-	headerStyle, err := f.NewStyle(&excelize.Style{
+// newHeaderStyle 創建標題樣式 (橘色背景 + 粗體 + 置中 + 邊框)
+func newHeaderStyle(f *excelize.File) (int, error) {
+	return f.NewStyle(&excelize.Style{
 		Font: &excelize.Font{
 			Bold: true,
 		},
@@ -169,173 +316,210 @@ func setupHeaders(f *excelize.File, sheetName string) error {
 			{Type: "right", Color: "000000", Style: 1},
 		},
 	})
-	if err != nil {
-		return err
-	}
+}
 
-	// 寫入標題
-	for i, header := range headers {
-		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
-		if err := f.SetCellValue(sheetName, cell, header); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, cell, cell, headerStyle); err != nil {
-			return err
-		}
+// dataStyles 資料列樣式
+type dataStyles struct {
+	// text 字串 (邊框 + 靠右對齊)
+	text int
+	// number 數字 (邊框 + 靠右對齊 + 小數點兩位)
+	number int
+	// integer 整數 (邊框 + 靠右對齊)
+	integer int
+	// invalidText 驗證失敗的字串 (紅底紅字)
+	invalidText int
+	// invalidNumber 驗證失敗的數字 (紅底紅字)
+	invalidNumber int
+	// invalidInteger 驗證失敗的整數 (紅底紅字)
+	invalidInteger int
+	// duplicateText 重複發票的字串 (黃底整列標示)
+	duplicateText int
+	// duplicateNumber 重複發票的數字 (黃底整列標示)
+	duplicateNumber int
+	// duplicateInteger 重複發票的整數 (黃底整列標示)
+	duplicateInteger int
+}
+
+// newDataStyles 創建資料列樣式
+func newDataStyles(f *excelize.File) (*dataStyles, error) {
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+	}
+	alignment := &excelize.Alignment{
+		Horizontal: "right",
+		Vertical:   "center",
+	}
+	invalidFill := excelize.Fill{
+		Type:    "pattern",
+		Color:   []string{"#FFC7CE"},
+		Pattern: 1,
+	}
+	invalidFont := &excelize.Font{
+		Color: "#9C0006",
+	}
+	duplicateFill := excelize.Fill{
+		Type:    "pattern",
+		Color:   []string{"#FFEB9C"},
+		Pattern: 1,
+	}
+	duplicateFont := &excelize.Font{
+		Color: "#9C5700",
 	}
 
-	// 凍結第一列
-	if err := f.SetPanes(sheetName, &excelize.Panes{
-		Freeze:      true,
-		YSplit:      1,
-		TopLeftCell: "A2",
-		ActivePane:  "bottomLeft",
+	styles := &dataStyles{}
+	var err error
+
+	if styles.text, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
 	}); err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
-}
+	if styles.number, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		NumFmt:    2, // 數字格式: 0.00
+	}); err != nil {
+		return nil, err
+	}
 
-// writeData 寫入資料到 Excel
-func writeData(f *excelize.File, sheetName string, records []*TaxRecord) error {
-	// 創建資料列樣式 (邊框 + 靠右對齊)
-	dataStyle, err := f.NewStyle(&excelize.Style{
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "right",
-			Vertical:   "center",
-		},
-	})
-	if err != nil {
-		return err
+	if styles.integer, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		NumFmt:    1, // 數字格式: 0
+	}); err != nil {
+		return nil, err
 	}
 
-	// 創建數字格式樣式 (邊框 + 靠右對齊 + 小數點兩位)
-	numberStyle, err := f.NewStyle(&excelize.Style{
-		Border: []excelize.Border{
-			{Type: "left", Color: "000000", Style: 1},
-			{Type: "top", Color: "000000", Style: 1},
-			{Type: "bottom", Color: "000000", Style: 1},
-			{Type: "right", Color: "000000", Style: 1},
-		},
-		Alignment: &excelize.Alignment{
-			Horizontal: "right",
-			Vertical:   "center",
-		},
-		NumFmt: 2, // 數字格式: 0.00
-	})
-	if err != nil {
-		return err
+	if styles.invalidText, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      invalidFill,
+		Font:      invalidFont,
+	}); err != nil {
+		return nil, err
 	}
 
-	row := 2 // 從第二列開始（第一列是標題）
+	if styles.invalidNumber, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      invalidFill,
+		Font:      invalidFont,
+		NumFmt:    2,
+	}); err != nil {
+		return nil, err
+	}
 
-	for _, record := range records {
-		col := 1
-
-		// 寫入各欄位資料
-
-		// 基礎資料 (字串)
-		values := []string{
-			record.FormatCode,
-			record.DeclarantTaxId,
-			record.SequenceNumber,
-			record.DataYear,
-			record.DataMonth,
-			record.BuyerTaxId,
-			// record.BusinessNumber,
-			record.SellerTaxId,
-		}
+	if styles.invalidInteger, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      invalidFill,
+		Font:      invalidFont,
+		NumFmt:    1,
+	}); err != nil {
+		return nil, err
+	}
 
-		// 發票(起)號碼 = 發票字軌 + 發票(起)號碼
-		invoiceNumber := record.InvoiceStartNumber
-		if len(strings.TrimSpace(record.InvoicePrefix)) > 0 {
-			invoiceNumber = record.InvoicePrefix + record.InvoiceStartNumber
-		}
-		values = append(values, invoiceNumber)
+	if styles.duplicateText, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      duplicateFill,
+		Font:      duplicateFont,
+	}); err != nil {
+		return nil, err
+	}
 
-		// 寫入字串欄位 (套用 dataStyle 靠右對齊)
-		for i, value := range values {
-			cell, _ := excelize.CoordinatesToCellName(col+i, row)
-			if err := f.SetCellValue(sheetName, cell, value); err != nil {
-				return err
-			}
-			if err := f.SetCellStyle(sheetName, cell, cell, dataStyle); err != nil {
-				return err
-			}
-		}
-		col += len(values)
+	if styles.duplicateNumber, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      duplicateFill,
+		Font:      duplicateFont,
+		NumFmt:    2,
+	}); err != nil {
+		return nil, err
+	}
 
-		// 銷售金額 (轉成數字,套用 numberStyle)
-		salesAmount := parseAmountToInt(record.SalesAmount)
-		salesCell, _ := excelize.CoordinatesToCellName(col, row)
-		if err := f.SetCellValue(sheetName, salesCell, salesAmount); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, salesCell, salesCell, numberStyle); err != nil {
-			return err
-		}
-		col++
+	if styles.duplicateInteger, err = f.NewStyle(&excelize.Style{
+		Border:    border,
+		Alignment: alignment,
+		Fill:      duplicateFill,
+		Font:      duplicateFont,
+		NumFmt:    1,
+	}); err != nil {
+		return nil, err
+	}
 
-		// 課稅別 (字串)
-		cell, _ := excelize.CoordinatesToCellName(col, row)
-		if err := f.SetCellValue(sheetName, cell, record.TaxType); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, cell, cell, dataStyle); err != nil {
-			return err
-		}
-		col++
+	return styles, nil
+}
 
-		// 營業稅額 (轉成數字,套用 numberStyle)
-		taxAmount := parseAmountToInt(record.TaxAmount)
-		taxCell, _ := excelize.CoordinatesToCellName(col, row)
-		if err := f.SetCellValue(sheetName, taxCell, taxAmount); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, taxCell, taxCell, numberStyle); err != nil {
-			return err
-		}
-		col++
+// writeData 依欄位設定寫入一筆資料到指定列，並回傳驗證問題（驗證失敗的儲存格會以紅底標示）
+// checks 為分配前跨資料檢查的結果：彙總登錄範圍的問題同樣以紅底標示，重複發票則整列以黃底標示
+func writeData(stream *excelize.StreamWriter, styles *dataStyles, row int, record *TaxRecord, profile *ColumnProfile, options ValidationOptions, checks crossRecordChecks) ([]ValidationIssue, error) {
+	// 驗證資料，記錄有問題的欄位
+	issues := append(ValidateRecord(record, options), checks.issues...)
+	duplicate := checks.duplicate
+	invalidFields := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		invalidFields[issue.Field] = true
+	}
+	if duplicate != nil {
+		issues = append(issues, duplicate.issue(record.SourceFileName, record.LineNumber))
+	}
 
-		// 扣抵代號 (字串)
-		cell, _ = excelize.CoordinatesToCellName(col, row)
-		if err := f.SetCellValue(sheetName, cell, record.DeductionCode); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, cell, cell, dataStyle); err != nil {
-			return err
+	cells := make([]interface{}, len(profile.Columns))
+	for i, column := range profile.Columns {
+		field := exportFields[column.Field]
+		value := field.value(record)
+
+		// 任一對應欄位驗證失敗時改用紅底樣式
+		invalid := false
+		for _, name := range field.validated {
+			if invalidFields[name] {
+				invalid = true
+				break
+			}
 		}
-		col++
 
-		// 彙加註記 (字串)
-		cell, _ = excelize.CoordinatesToCellName(col, row)
-		if err := f.SetCellValue(sheetName, cell, record.AggregationMark); err != nil {
-			return err
-		}
-		if err := f.SetCellStyle(sheetName, cell, cell, dataStyle); err != nil {
-			return err
+		if column.columnType() == ColumnNumber {
+			style := styles.number
+			if field.integer {
+				style = styles.integer
+			}
+			if duplicate != nil {
+				style = styles.duplicateNumber
+				if field.integer {
+					style = styles.duplicateInteger
+				}
+			}
+			if invalid {
+				style = styles.invalidNumber
+				if field.integer {
+					style = styles.invalidInteger
+				}
+			}
+			cells[i] = excelize.Cell{StyleID: style, Value: parseAmountToInt(value)}
+		} else {
+			style := styles.text
+			if duplicate != nil {
+				style = styles.duplicateText
+			}
+			if invalid {
+				style = styles.invalidText
+			}
+			cells[i] = excelize.Cell{StyleID: style, Value: value}
 		}
-		col++
-
-		row++
 	}
 
-	// 自動調整欄寬
-	for i := 1; i <= 16; i++ {
-		col, _ := excelize.ColumnNumberToName(i)
-		if err := f.SetColWidth(sheetName, col, col, 15); err != nil {
-			return err
-		}
+	cell, _ := excelize.CoordinatesToCellName(1, row)
+	if err := stream.SetRow(cell, cells); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return issues, nil
 }
 
 // parseAmountToInt 將金額字串轉換成整數
@@ -0,0 +1,239 @@
+package core
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"accountingTools/apps/internal/benchdata"
+)
+
+// benchRows BenchmarkExportToExcel 的資料筆數，例如 go test -run '^$' -bench ExportToExcel -benchmem -bench-rows 1000000
+var benchRows = flag.Int("bench-rows", 10000, "BenchmarkExportToExcel 的資料筆數")
+
+// BenchmarkExportToExcel 比較 StreamWriter 逐筆寫入與改版前整批載入後逐格 SetCellValue 的效能
+func BenchmarkExportToExcel(b *testing.B) {
+	rows := *benchRows
+	tempDir := b.TempDir()
+	txtFile := filepath.Join(tempDir, "bench.txt")
+	if err := benchdata.WriteFile(txtFile, rows); err != nil {
+		b.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		export func(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) error
+	}{
+		{
+			name: "StreamWriter",
+			export: func(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) error {
+				_, err := ExportToExcel(ctx, allocation, outputFolder, rows, 0, nil, DefaultValidationOptions(), nil)
+				return err
+			},
+		},
+		{
+			name:   "SetCellValue",
+			export: exportWithSetCellValue,
+		},
+	}
+	for _, tc := range cases {
+		b.Run(fmt.Sprintf("%s/rows=%d", tc.name, rows), func(b *testing.B) {
+			ctx := context.Background()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				outputFolder, err := os.MkdirTemp(tempDir, tc.name+"_")
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 0)
+				if err != nil {
+					b.Fatal(err)
+				}
+				allocation, err := ValidateAndAllocateFiles(ctx, fileInfoList, rows, 1, StrategyNextFit, false)
+				if err != nil {
+					ReleaseFiles(fileInfoList)
+					b.Fatal(err)
+				}
+				if err := tc.export(ctx, allocation, outputFolder); err != nil {
+					ReleaseFiles(fileInfoList)
+					b.Fatal(err)
+				}
+				ReleaseFiles(fileInfoList)
+			}
+		})
+	}
+}
+
+// exportWithSetCellValue 改版前的匯出方式（效能比較基準）：整個 Excel 的資料先載入記憶體，再逐格 SetCellValue 並套用樣式
+func exportWithSetCellValue(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) error {
+	for i, fileGroup := range allocation {
+		records := make([]*TaxRecord, 0)
+		for _, fileInfo := range fileGroup {
+			if err := fileInfo.EachRecord(func(record *TaxRecord) error {
+				records = append(records, record)
+				return nil
+			}); err != nil {
+				return err
+			}
+		}
+
+		f := excelize.NewFile()
+		styles, err := newDataStyles(f)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		for col, column := range DefaultColumnProfile.Columns {
+			cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+			if err := f.SetCellValue("Sheet1", cell, column.header()); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		for row, record := range records {
+			ValidateRecord(record, DefaultValidationOptions())
+			for col, column := range DefaultColumnProfile.Columns {
+				cell, _ := excelize.CoordinatesToCellName(col+1, row+2)
+				value := exportFields[column.Field].value(record)
+				style := styles.text
+				var cellValue interface{} = value
+				if column.columnType() == ColumnNumber {
+					style = styles.number
+					cellValue = parseAmountToInt(value)
+				}
+				if err := f.SetCellValue("Sheet1", cell, cellValue); err != nil {
+					f.Close()
+					return err
+				}
+				if err := f.SetCellStyle("Sheet1", cell, cell, style); err != nil {
+					f.Close()
+					return err
+				}
+			}
+		}
+		err = f.SaveAs(filepath.Join(outputFolder, fmt.Sprintf("baseline_%d.xlsx", i+1)))
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func TestDataStylesNumberFormats(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newDataStyles(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		style  int
+		numFmt int
+	}{
+		{name: "金額", style: styles.number, numFmt: 2},
+		{name: "整數", style: styles.integer, numFmt: 1},
+		{name: "驗證失敗的金額", style: styles.invalidNumber, numFmt: 2},
+		{name: "驗證失敗的整數", style: styles.invalidInteger, numFmt: 1},
+		{name: "重複發票的金額", style: styles.duplicateNumber, numFmt: 2},
+		{name: "重複發票的整數", style: styles.duplicateInteger, numFmt: 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			style, err := f.GetStyle(tc.style)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if style.NumFmt != tc.numFmt {
+				t.Errorf("數字格式 = %d，應為 %d", style.NumFmt, tc.numFmt)
+			}
+		})
+	}
+}
+
+func TestExportToExcelWritesSequenceSheet(t *testing.T) {
+	txtFile := writeTestTxt(t, t.TempDir(), "sequence.txt",
+		sequenceInvoice("01", 50, "1"), sequenceInvoice("01", 52, "1"), sequenceBlank("01", 53, 99),
+	)
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	outputFolder := t.TempDir()
+	allocation := [][]*TxtFileInfo{fileInfoList}
+	if _, err := ExportToExcel(ctx, allocation, outputFolder, 100, 1, nil, DefaultValidationOptions(), nil); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := filepath.Glob(filepath.Join(outputFolder, "*.xlsx"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("產出 %d 個 Excel（%v），應為 1 個", len(matches), err)
+	}
+
+	f, err := excelize.OpenFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if sheets := f.GetSheetList(); sheets[0] != dataSheetName {
+		t.Errorf("第一個工作表 = %s，應為 %s", sheets[0], dataSheetName)
+	}
+	rows, err := f.GetRows(sequenceSheetName)
+	if err != nil {
+		t.Fatalf("沒有「%s」工作表: %v", sequenceSheetName, err)
+	}
+	// 標題、字軌範圍、缺號 51
+	if len(rows) != 3 || rows[2][3] != SequenceGap.String() || rows[2][4] != "00000051" {
+		t.Errorf("字軌檢查工作表 = %v，應列出缺號 00000051", rows)
+	}
+}
+
+func TestExportToExcelEnforcesMaxRows(t *testing.T) {
+	txtFile := writeTestTxt(t, t.TempDir(), "rows.txt", splitRecords(3)...)
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+	allocation := [][]*TxtFileInfo{fileInfoList}
+
+	cases := []struct {
+		name    string
+		maxRows int
+		wantErr bool
+	}{
+		{name: "剛好等於最大筆數", maxRows: 3},
+		{name: "超過最大筆數", maxRows: 2, wantErr: true},
+		{name: "未設定時以 Excel 上限為準", maxRows: 0},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			outputFolder := t.TempDir()
+			_, err := ExportToExcel(ctx, allocation, outputFolder, tc.maxRows, 1, nil, DefaultValidationOptions(), nil)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("錯誤 = %v，應回傳錯誤 = %v", err, tc.wantErr)
+			}
+			// 失敗時不留下寫到一半的檔案
+			want := 1
+			if tc.wantErr {
+				want = 0
+			}
+			matches, _ := filepath.Glob(filepath.Join(outputFolder, "*.xlsx"))
+			if len(matches) != want {
+				t.Errorf("產出 %d 個 Excel，應為 %d 個", len(matches), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,419 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 匯出工作表中合併或依類別共用的欄位標題
+const (
+	// voucherHeader 發票字軌 + 發票(起)號碼 合併欄位（其他憑證為憑證號碼）
+	voucherHeader = "發票(起)號碼"
+	// amountHeader 銷售金額欄位（海關繳納證為營業稅稅基）
+	amountHeader = "銷售金額"
+)
+
+// idFieldLengths 以數字組成的編號欄位長度（Excel 可能將其轉為數字而去掉前導零）
+var idFieldLengths = map[string]int{
+	"DeclarantTaxId": 9,
+	"SequenceNumber": 7,
+	"BuyerTaxId":     8,
+	"SellerTaxId":    8,
+}
+
+// ConvertError 儲存格無法轉回媒體檔格式
+type ConvertError struct {
+	// Cell 儲存格位置（例如 B12）
+	Cell string
+
+	// Field 欄位名稱（TaxRecord 欄位名）
+	Field string
+
+	// Value 儲存格內容
+	Value string
+
+	// Reason 錯誤說明
+	Reason string
+}
+
+// Error 錯誤描述
+func (e ConvertError) Error() string {
+	return fmt.Sprintf("儲存格 %s %s [%s]: %s", e.Cell, FieldLabel(e.Field), e.Value, e.Reason)
+}
+
+// ImportExcelToTxt 讀取 ExportToExcel 產出（或人工修正後）的工作簿，轉回媒體申報 TXT 檔
+// 以標題列辨識欄位，「發票(起)號碼」拆回字軌與號碼，金額儲存格轉回補零字串
+// 單一 Excel 多工作表（ExportToSingleWorkbook）時，依序轉出目錄以外的所有資料工作表
+// 無法轉換的資料列不會寫入，錯誤連同儲存格位置回傳；工作表沒有空白欄位時提醒這些位置將轉為空白
+func ImportExcelToTxt(xlsxPath string, outputPath string) ([]ConvertError, error) {
+	f, err := excelize.OpenFile(xlsxPath)
+	if err != nil {
+		return nil, fmt.Errorf("開啟 Excel 檔案失敗: %v", err)
+	}
+	defer f.Close()
+
+	sheetNames := importSheetNames(f)
+	if len(sheetNames) == 0 {
+		return nil, fmt.Errorf("找不到「%s」工作表", dataSheetName)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("建立 TXT 檔案失敗: %v", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	convertErrors := make([]ConvertError, 0)
+	sourceFileName := filepath.Base(xlsxPath)
+	recordCount := 0
+
+	writeErr := func() error {
+		for _, sheetName := range sheetNames {
+			sheetErrors, count, err := importSheet(f, sheetName, writer, sourceFileName, len(sheetNames) > 1)
+			convertErrors = append(convertErrors, sheetErrors...)
+			recordCount += count
+			if err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	}()
+
+	closeErr := file.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		os.Remove(outputPath)
+		return convertErrors, fmt.Errorf("產生 TXT 檔案失敗: %v", writeErr)
+	}
+
+	fmt.Printf("✓ 已轉出 %d 筆資料: %s\n", recordCount, filepath.Base(outputPath))
+	return convertErrors, nil
+}
+
+// importSheetNames 要轉回 TXT 的資料工作表
+// 有「營業人進銷項資料」工作表時只轉出該表，否則為目錄、彙總、403 試算、作廢及空白發票、字軌檢查、來源檔案與報告以外的所有工作表
+func importSheetNames(f *excelize.File) []string {
+	if index, err := f.GetSheetIndex(dataSheetName); err == nil && index >= 0 {
+		return []string{dataSheetName}
+	}
+
+	reportSheets := map[string]bool{
+		tocSheetName:        true,
+		summarySheetName:    true,
+		return403SheetName:  true,
+		voidedSheetName:     true,
+		sequenceSheetName:   true,
+		sourceSheetName:     true,
+		validationSheetName: true,
+		parseErrorSheetName: true,
+	}
+	sheetNames := make([]string, 0)
+	for _, sheetName := range f.GetSheetList() {
+		if !reportSheets[sheetName] {
+			sheetNames = append(sheetNames, sheetName)
+		}
+	}
+	return sheetNames
+}
+
+// importSheet 將一個資料工作表轉回固定長度資料並寫入 writer，回傳無法轉換的儲存格與寫入筆數
+// withSheetName 為 true 時，錯誤的儲存格位置加上工作表名稱（例如 資料_2!B12）
+func importSheet(f *excelize.File, sheetName string, writer *bufio.Writer, sourceFileName string, withSheetName bool) ([]ConvertError, int, error) {
+	rows, err := f.Rows(sheetName)
+	if err != nil {
+		return nil, 0, fmt.Errorf("找不到「%s」工作表: %v", sheetName, err)
+	}
+	defer rows.Close()
+
+	// 讀取標題列
+	if !rows.Next() {
+		return nil, 0, fmt.Errorf("「%s」工作表沒有資料", sheetName)
+	}
+	headers, err := rows.Columns()
+	if err != nil {
+		return nil, 0, err
+	}
+	columns := mapImportColumns(headers)
+	if missing := missingImportColumns(columns, commonImportColumns); len(missing) > 0 {
+		return nil, 0, fmt.Errorf("「%s」工作表缺少欄位：%s，無法轉回媒體檔", sheetName, strings.Join(missing, "、"))
+	}
+	if missing := missingImportColumns(columns, blankImportColumns); len(missing) > 0 {
+		fmt.Printf("⚠ 「%s」工作表沒有%s欄位，轉出的 TXT 這些位置將為空白（原始資料在此有內容時會遺失）\n", sheetName, strings.Join(missing, "、"))
+	}
+
+	convertErrors := make([]ConvertError, 0)
+	addSheetName := func(errs []ConvertError) []ConvertError {
+		if withSheetName {
+			for i := range errs {
+				errs[i].Cell = sheetName + "!" + errs[i].Cell
+			}
+		}
+		return errs
+	}
+	rowNumber := 1
+	recordCount := 0
+
+	for rows.Next() {
+		rowNumber++
+		cells, err := rows.Columns(excelize.Options{RawCellValue: true})
+		if err != nil {
+			return convertErrors, recordCount, err
+		}
+		if isBlankRow(cells) {
+			continue
+		}
+
+		record, errs := buildRecordFromRow(cells, columns, rowNumber)
+		if len(errs) > 0 {
+			convertErrors = append(convertErrors, addSheetName(errs)...)
+			continue
+		}
+		if missing := missingImportColumns(columns, recordImportColumns(record)); len(missing) > 0 {
+			cell, _ := excelize.CoordinatesToCellName(columns["FormatCode"]+1, rowNumber)
+			convertErrors = append(convertErrors, addSheetName([]ConvertError{{
+				Cell:   cell,
+				Field:  "FormatCode",
+				Value:  record.FormatCode,
+				Reason: fmt.Sprintf("工作表缺少%s資料需要的欄位：%s", record.Kind, strings.Join(missing, "、")),
+			}})...)
+			continue
+		}
+		record.SourceFileName = sourceFileName
+		record.LineNumber = rowNumber
+
+		if err := writeTxtRecord(writer, record); err != nil {
+			cell, _ := excelize.CoordinatesToCellName(1, rowNumber)
+			convertErrors = append(convertErrors, addSheetName([]ConvertError{{Cell: cell, Reason: err.Error()}})...)
+			continue
+		}
+		recordCount++
+	}
+
+	return convertErrors, recordCount, rows.Error()
+}
+
+// commonImportColumns 所有資料類別轉回媒體檔都需要的欄位（「發票(起)號碼」、「銷售金額」以標題文字表示）
+var commonImportColumns = []string{
+	"FormatCode", "DeclarantTaxId", "SequenceNumber", "DataYear", "DataMonth",
+	amountHeader, "TaxType", "TaxAmount", "DeductionCode", "SpecialTaxRate", "CustomsClearanceMark",
+}
+
+// blankImportColumns 申報格式中的空白欄位：可省略，但原始資料在此有內容（例如備註）時，省略會使內容遺失
+var blankImportColumns = []string{"Blank1", "Blank2", "Blank3"}
+
+// recordImportColumns 依資料類別（與 FormatRecord 寫入的欄位相同）另外需要的欄位
+func recordImportColumns(record *TaxRecord) []string {
+	columns := make([]string, 0, 5)
+	switch record.Kind {
+	case KindInvoice:
+		if record.IsOutput() && record.IsBlank() {
+			columns = append(columns, "BusinessNumber")
+		} else {
+			columns = append(columns, "BuyerTaxId")
+		}
+		columns = append(columns, "SellerTaxId", voucherHeader)
+	case KindOtherVoucher, KindUtilityVoucher:
+		columns = append(columns, "BuyerTaxId", "SellerTaxId", voucherHeader)
+	case KindSummary:
+		columns = append(columns, "BusinessNumber", "TotalSheets", voucherHeader)
+	case KindCustoms:
+		columns = append(columns, "BuyerTaxId", voucherHeader)
+	}
+	if record.IsOutput() {
+		columns = append(columns, "AggregationMark")
+	} else {
+		columns = append(columns, "AllocationMark")
+	}
+	return columns
+}
+
+// missingImportColumns 工作表缺少的欄位標題
+func missingImportColumns(columns map[string]int, required []string) []string {
+	missing := make([]string, 0)
+	for _, column := range required {
+		if _, ok := columns[column]; ok {
+			continue
+		}
+		if column == voucherHeader || column == amountHeader {
+			missing = append(missing, column)
+		} else {
+			missing = append(missing, FieldLabel(column))
+		}
+	}
+	return missing
+}
+
+// mapImportColumns 依標題文字對應欄位位置（0 起算）
+// 一般欄位以欄位中文名稱對應；「發票(起)號碼」、「銷售金額」另行處理
+func mapImportColumns(headers []string) map[string]int {
+	labelToField := make(map[string]string, len(fieldLabels))
+	for field, label := range fieldLabels {
+		labelToField[label] = field
+	}
+
+	columns := make(map[string]int)
+	for i, header := range headers {
+		header = strings.TrimSpace(header)
+		switch header {
+		case voucherHeader:
+			columns[voucherHeader] = i
+		case amountHeader:
+			columns[amountHeader] = i
+		default:
+			if field, ok := labelToField[header]; ok {
+				if _, exists := recordFields[field]; exists {
+					columns[field] = i
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// buildRecordFromRow 由工作表資料列重建 TaxRecord
+func buildRecordFromRow(cells []string, columns map[string]int, rowNumber int) (*TaxRecord, []ConvertError) {
+	record := &TaxRecord{}
+	errs := make([]ConvertError, 0)
+
+	cellValue := func(column int) string {
+		if column < len(cells) {
+			return strings.TrimSpace(cells[column])
+		}
+		return ""
+	}
+	addError := func(column int, field, value, reason string) {
+		cell, _ := excelize.CoordinatesToCellName(column+1, rowNumber)
+		errs = append(errs, ConvertError{Cell: cell, Field: field, Value: value, Reason: reason})
+	}
+
+	// 一般欄位
+	for field, column := range columns {
+		accessor, ok := recordFields[field]
+		if !ok {
+			continue
+		}
+		value := cellValue(column)
+
+		if length, ok := numericFieldLengths[field]; ok {
+			digits, err := toZeroPaddedDigits(value, length)
+			if err != nil {
+				addError(column, field, value, err.Error())
+				continue
+			}
+			value = digits
+		} else if length, ok := idFieldLengths[field]; ok {
+			value = padDigits(value, length)
+		}
+
+		*accessor(record) = value
+	}
+
+	// 依格式代號與憑證號碼判定資料類別
+	voucher := ""
+	if column, ok := columns[voucherHeader]; ok {
+		voucher = cellValue(column)
+	}
+	track := ""
+	if len(voucher) >= 2 {
+		track = voucher[:2]
+	}
+	record.Kind = detectRecordKind(record.FormatCode, track)
+	if record.Kind == KindUnknown {
+		addError(columns["FormatCode"], "FormatCode", record.FormatCode, "未知的格式代號")
+		return nil, errs
+	}
+
+	// 拆回憑證號碼
+	if column, ok := columns[voucherHeader]; ok {
+		switch {
+		case record.Kind == KindInvoice || (record.Kind == KindSummary && isInvoiceTrack(track)):
+			if len(voucher) != 10 || !isDigits(voucher[2:]) {
+				addError(column, "InvoiceStartNumber", voucher, "發票號碼應為 2 碼字軌 + 8 碼數字")
+			} else {
+				record.InvoicePrefix = voucher[:2]
+				record.InvoiceStartNumber = voucher[2:]
+			}
+		case record.Kind == KindOtherVoucher || record.Kind == KindSummary:
+			record.OtherVoucherNumber = voucher
+		case record.Kind == KindUtilityVoucher:
+			record.UtilitySequenceNumber = voucher
+		case record.Kind == KindCustoms:
+			record.CustomsTaxPaymentNumber = voucher
+		}
+	}
+
+	// 金額欄位（海關繳納證為營業稅稅基）
+	if column, ok := columns[amountHeader]; ok {
+		field := "SalesAmount"
+		if record.Kind == KindCustoms {
+			field = "TaxBase"
+		}
+		value := cellValue(column)
+		digits, err := toZeroPaddedDigits(value, numericFieldLengths[field])
+		if err != nil {
+			addError(column, field, value, err.Error())
+		} else {
+			*recordFields[field](record) = digits
+		}
+	}
+
+	// 位置 80 依進銷項只保留對應的註記
+	if record.IsOutput() {
+		record.AllocationMark = ""
+	} else {
+		record.AggregationMark = ""
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return record, nil
+}
+
+// toZeroPaddedDigits 將儲存格內容轉為指定長度的補零數字字串
+// 可接受文字數字（"000123"）或數值（123、123.00）；空白回傳空字串
+func toZeroPaddedDigits(value string, length int) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	if !isDigits(value) {
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || number < 0 || number != math.Trunc(number) {
+			return "", fmt.Errorf("無法轉換為 %d 位數字", length)
+		}
+		value = strconv.FormatInt(int64(number), 10)
+	}
+
+	if len(value) > length {
+		return "", fmt.Errorf("超過 %d 位數字", length)
+	}
+	return strings.Repeat("0", length-len(value)) + value, nil
+}
+
+// padDigits 數字編號補足前導零（非純數字則原樣回傳）
+func padDigits(value string, length int) string {
+	if isDigits(value) && len(value) < length {
+		return strings.Repeat("0", length-len(value)) + value
+	}
+	return value
+}
+
+// isBlankRow 是否為空白列
+func isBlankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,167 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// remarkLines 各資料類別的範例資料行，空白欄位填入備註（原始檔在空白位置有內容時也須原樣轉回）
+func remarkLines(t *testing.T) []string {
+	t.Helper()
+	lines := make([]string, 0, len(roundTripCases))
+	for _, tc := range roundTripCases {
+		record, err := ParseLine(tc.line, 1, "sample.txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch record.Kind {
+		case KindSummary:
+			record.Blank1 = "A1"
+		case KindCustoms:
+			record.Blank2 = "備註"
+		}
+		record.Blank3 = "備註"
+		data, err := FormatRecord(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, fromSpecBytes(data))
+	}
+	return lines
+}
+
+// exportTestWorkbook 將資料行寫入 TXT 後以預設欄位設定匯出 Excel，回傳 Excel 路徑
+func exportTestWorkbook(t *testing.T, lines []string) string {
+	t.Helper()
+	dir := t.TempDir()
+	txtFile := filepath.Join(dir, "source.txt")
+	if err := os.WriteFile(txtFile, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	outputFolder := filepath.Join(dir, "output")
+	if err := os.Mkdir(outputFolder, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ExportToExcel(ctx, [][]*TxtFileInfo{fileInfoList}, outputFolder, 0, 1, nil, DefaultValidationOptions(), nil); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := filepath.Glob(filepath.Join(outputFolder, "*.xlsx"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("產出 %d 個 Excel（%v），應為 1 個", len(matches), err)
+	}
+	return matches[0]
+}
+
+// readImportedLines 讀取轉出的 TXT（Big5），每行轉為 UTF-8
+func readImportedLines(t *testing.T, txtPath string) []string {
+	t.Helper()
+	data, err := os.ReadFile(txtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := make([]string, 0)
+	for _, line := range bytes.Split(bytes.TrimSuffix(data, []byte("\r\n")), []byte("\r\n")) {
+		lines = append(lines, fromSpecBytes(line))
+	}
+	return lines
+}
+
+func TestImportExcelToTxtRoundTrip(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines func(t *testing.T) []string
+	}{
+		{name: "各資料類別", lines: func(t *testing.T) []string {
+			lines := make([]string, len(roundTripCases))
+			for i, tc := range roundTripCases {
+				lines[i] = tc.line
+			}
+			return lines
+		}},
+		{name: "空白欄位有備註", lines: remarkLines},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			lines := tc.lines(t)
+			xlsxPath := exportTestWorkbook(t, lines)
+			txtPath := filepath.Join(t.TempDir(), "imported.txt")
+			convertErrors, err := ImportExcelToTxt(xlsxPath, txtPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(convertErrors) != 0 {
+				t.Fatalf("轉換錯誤 %v，應沒有錯誤", convertErrors)
+			}
+
+			got := readImportedLines(t, txtPath)
+			if len(got) != len(lines) {
+				t.Fatalf("轉出 %d 行，應為 %d 行", len(got), len(lines))
+			}
+			for i := range lines {
+				if got[i] != lines[i] {
+					t.Errorf("第 %d 行\n  轉出: %q\n  原始: %q", i+1, got[i], lines[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImportExcelToTxtConvertErrors(t *testing.T) {
+	lines := make([]string, len(roundTripCases))
+	for i, tc := range roundTripCases {
+		lines[i] = tc.line
+	}
+	xlsxPath := exportTestWorkbook(t, lines)
+
+	// 人工修改第 2 筆資料（第 3 列）的營業稅額為無法轉換的內容
+	f, err := excelize.OpenFile(xlsxPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := 0
+	for i, c := range DefaultColumnProfile.Columns {
+		if c.Field == "TaxAmount" {
+			column = i + 1
+		}
+	}
+	cell, _ := excelize.CoordinatesToCellName(column, 3)
+	if err := f.SetCellValue(dataSheetName, cell, "五十"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	txtPath := filepath.Join(t.TempDir(), "imported.txt")
+	convertErrors, err := ImportExcelToTxt(xlsxPath, txtPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(convertErrors) != 1 {
+		t.Fatalf("轉換錯誤 %v，應只有 1 個", convertErrors)
+	}
+	if got := convertErrors[0]; got.Cell != cell || got.Field != "TaxAmount" || got.Value != "五十" {
+		t.Errorf("轉換錯誤 = %+v，應為儲存格 %s 的營業稅額", got, cell)
+	}
+
+	// 無法轉換的資料列不寫入，其餘資料照常轉出
+	got := readImportedLines(t, txtPath)
+	want := append(append([]string{}, lines[:1]...), lines[2:]...)
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("轉出 %q，應為 %q", got, want)
+	}
+}
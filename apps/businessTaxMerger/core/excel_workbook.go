@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// tocSheetName 目錄工作表名稱
+const tocSheetName = "目錄"
+
+// maxSheetNameLength Excel 工作表名稱長度上限
+const maxSheetNameLength = 31
+
+// ExportToSingleWorkbook 將所有分配結果匯出到單一 Excel，每個分配各為一個工作表，並回傳驗證報告
+// 工作表以編號命名（資料_1、資料_2…），分組時以分組值命名（123456789_1…）
+// maxRowsPerExcel 為每個資料工作表的資料筆數上限（<= 0 或超過 MaxExcelDataRows 時以 MaxExcelDataRows 為準），超過時匯出失敗
+// 第一個工作表為目錄，可點選連結到各資料工作表；ctx 取消或失敗時不會留下寫到一半的檔案
+// profile 為資料工作表的欄位設定，nil 時使用預設欄位設定；periods 為 AnalyzePeriods 的結果，nil 時寫完資料後再讀取一次計算
+func ExportToSingleWorkbook(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string, maxRowsPerExcel int, profile *ColumnProfile, options ValidationOptions, periods *PeriodReport) (*ValidationReport, error) {
+	if profile == nil {
+		profile = DefaultColumnProfile
+	}
+	timestamp := time.Now().Format("20060102_150405")
+	fileName := fmt.Sprintf("營業人進銷項資料_%s.xlsx", timestamp)
+	fullPath := filepath.Join(outputFolder, fileName)
+	report := &ValidationReport{}
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	// 目錄放在第一個工作表
+	if err := f.SetSheetName("Sheet1", tocSheetName); err != nil {
+		return report, err
+	}
+
+	styles, err := newDataStyles(f)
+	if err != nil {
+		return report, err
+	}
+
+	totals := newControlTotals()
+	sheetNames := make([]string, len(allocation))
+	recordCounts := make([]int, len(allocation))
+	for i, fileGroup := range allocation {
+		sheetNames[i] = allocationSheetName(fileGroup, i+1)
+		fmt.Printf("正在寫入工作表「%s」（%d 個 TXT 檔案）...\n", sheetNames[i], len(fileGroup))
+
+		sheetReport, recordCount, err := writeDataSheet(ctx, f, sheetNames[i], fileGroup, maxRowsPerExcel, profile, options, styles, totals)
+		if err != nil {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			return report, fmt.Errorf("寫入工作表「%s」失敗: %v", sheetNames[i], err)
+		}
+		report.Merge(sheetReport)
+		recordCounts[i] = recordCount
+
+		fmt.Printf("  ✓ 已寫入 %d 筆資料\n", recordCount)
+		for _, fileInfo := range fileGroup {
+			if fileInfo.IsPart() {
+				fmt.Printf("    含 %s [%s]\n", fileInfo.FileName, fileInfo.PartDescription())
+			}
+		}
+	}
+
+	if err := writeTocSheet(f, sheetNames, allocation, recordCounts); err != nil {
+		return report, err
+	}
+
+	// 寫入彙總、403 試算（兼營應稅及免稅時）、作廢及空白發票、字軌檢查（有銷項發票時）、來源檔案、驗證報告與錯誤清單（所有工作表合併列出）
+	if err := writeSummarySheet(f, totals); err != nil {
+		return report, err
+	}
+	if periods == nil {
+		if periods, err = AnalyzePeriods(ctx, allocation); err != nil {
+			return report, err
+		}
+	}
+	if returns := periods.returns403.mixedReturns(totals.periods); len(returns) > 0 {
+		if err := writeReturn403Sheet(f, returns); err != nil {
+			return report, err
+		}
+	}
+	if len(totals.voided) > 0 {
+		if err := writeVoidedSheet(f, totals.voided); err != nil {
+			return report, err
+		}
+	}
+	// 銷項發票字軌檢查（跨所有工作表）
+	if len(periods.Sequence.Tracks) > 0 {
+		if err := writeSequenceSheet(f, periods.Sequence); err != nil {
+			return report, err
+		}
+	}
+	if err := writeSourceSheet(f, sheetNames, allocation); err != nil {
+		return report, err
+	}
+	if report.HasIssues() {
+		if err := writeValidationSheet(f, report.Issues); err != nil {
+			return report, err
+		}
+	}
+	if report.HasParseErrors() {
+		if err := writeParseErrorSheet(f, report.ParseErrors); err != nil {
+			return report, err
+		}
+	}
+
+	// 目錄是第一個工作表，開啟時即顯示；不呼叫 SetActiveSheet，它會把 StreamWriter 寫好的資料工作表整份讀回記憶體
+
+	// 儲存檔案（儲存前再確認一次是否已取消）
+	if err := ctx.Err(); err != nil {
+		return report, err
+	}
+	if err := f.SaveAs(fullPath); err != nil {
+		// 移除寫到一半的檔案
+		os.Remove(fullPath)
+		return report, fmt.Errorf("產生 Excel 檔案失敗: %v", err)
+	}
+
+	fmt.Printf("✓ 已產出: %s（%d 個工作表）\n", fileName, len(allocation))
+	if report.HasParseErrors() {
+		fmt.Printf("⚠ %d 個結構錯誤的資料行未匯入，請參閱「%s」工作表\n", len(report.ParseErrors), parseErrorSheetName)
+	}
+	if report.HasIssues() {
+		fmt.Printf("⚠ 發現 %d 個驗證問題，請參閱「%s」工作表\n", len(report.Issues), validationSheetName)
+	}
+
+	return report, nil
+}
+
+// allocationSheetName 資料工作表名稱：未分組為「資料_編號」，分組為「分組值_編號」
+func allocationSheetName(fileGroup []*TxtFileInfo, number int) string {
+	suffix := fmt.Sprintf("_%d", number)
+	prefix := "資料"
+	if len(fileGroup) > 0 && fileGroup[0].GroupBy != GroupByNone {
+		prefix = groupFileNamePart(fileGroup[0])
+	}
+
+	// 工作表名稱最多 31 字，過長時截短分組值
+	if runes := []rune(prefix); len(runes)+len(suffix) > maxSheetNameLength {
+		prefix = string(runes[:maxSheetNameLength-len(suffix)])
+	}
+	return prefix + suffix
+}
+
+// writeTocSheet 寫入目錄工作表：各資料工作表的連結、分組、資料筆數與來源檔案
+func writeTocSheet(f *excelize.File, sheetNames []string, allocation [][]*TxtFileInfo, recordCounts []int) error {
+	headerStyle, err := newHeaderStyle(f)
+	if err != nil {
+		return err
+	}
+	linkStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Color: "#0563C1", Underline: "single"},
+	})
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"工作表", "分組", "資料筆數", "來源檔案"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.SetCellValue(tocSheetName, cell, header); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(tocSheetName, cell, cell, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	for i, sheetName := range sheetNames {
+		row := i + 2
+		group := ""
+		sources := make([]string, 0, len(allocation[i]))
+		for _, fileInfo := range allocation[i] {
+			if group == "" {
+				group = fileInfo.GroupLabel()
+			}
+			if fileInfo.IsPart() {
+				sources = append(sources, fmt.Sprintf("%s [%s]", fileInfo.FileName, fileInfo.PartDescription()))
+			} else {
+				sources = append(sources, fileInfo.FileName)
+			}
+		}
+
+		values := []interface{}{sheetName, group, recordCounts[i], strings.Join(sources, "、")}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			if err := f.SetCellValue(tocSheetName, cell, value); err != nil {
+				return err
+			}
+		}
+
+		// 工作表名稱連結到該工作表的 A1
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		if err := f.SetCellHyperLink(tocSheetName, cell, fmt.Sprintf("'%s'!A1", sheetName), "Location"); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(tocSheetName, cell, cell, linkStyle); err != nil {
+			return err
+		}
+	}
+
+	// 設定欄寬
+	widths := []float64{20, 24, 12, 60}
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(tocSheetName, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestExportToSingleWorkbook(t *testing.T) {
+	dir := t.TempDir()
+	a := writeTestTxt(t, dir, "a.txt", splitRecords(2)...)
+	b := writeTestTxt(t, dir, "b.txt", splitRecords(3)...)
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{a, b}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+	allocation := [][]*TxtFileInfo{{fileInfoList[0]}, {fileInfoList[1]}}
+
+	outputFolder := t.TempDir()
+	if _, err := ExportToSingleWorkbook(ctx, allocation, outputFolder, 3, nil, DefaultValidationOptions(), nil); err != nil {
+		t.Fatal(err)
+	}
+	matches, err := filepath.Glob(filepath.Join(outputFolder, "*.xlsx"))
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("產出 %d 個 Excel（%v），應為 1 個", len(matches), err)
+	}
+	f, err := excelize.OpenFile(matches[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if sheets := f.GetSheetList(); len(sheets) < 3 || sheets[0] != tocSheetName || sheets[1] != "資料_1" || sheets[2] != "資料_2" {
+		t.Fatalf("工作表 = %v，應依序為 %s、資料_1、資料_2", sheets, tocSheetName)
+	}
+
+	cases := []struct {
+		sheet   string
+		source  string
+		records int
+	}{
+		{sheet: "資料_1", source: "a.txt", records: 2},
+		{sheet: "資料_2", source: "b.txt", records: 3},
+	}
+	for i, tc := range cases {
+		t.Run(tc.sheet, func(t *testing.T) {
+			// 目錄：工作表名稱連結到該工作表的 A1，並列出資料筆數與來源檔案
+			row := i + 2
+			cell, _ := excelize.CoordinatesToCellName(1, row)
+			link, target, err := f.GetCellHyperLink(tocSheetName, cell)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "'" + tc.sheet + "'!A1"; !link || target != want {
+				t.Errorf("目錄 %s 的連結 = %v %q，應連結到 %q", cell, link, target, want)
+			}
+			tocRows, err := f.GetRows(tocSheetName)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := tocRows[row-1]; len(got) < 4 || got[0] != tc.sheet || got[2] != strconv.Itoa(tc.records) || got[3] != tc.source {
+				t.Errorf("目錄第 %d 列 = %v，應為 %s、%d 筆、%s", row, got, tc.sheet, tc.records, tc.source)
+			}
+
+			// 資料工作表：標題列加上各筆資料
+			rows, err := f.GetRows(tc.sheet)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(rows) != tc.records+1 {
+				t.Errorf("工作表「%s」有 %d 列，應為 %d 列（含標題列）", tc.sheet, len(rows), tc.records+1)
+			}
+		})
+	}
+}
+
+func TestExportToSingleWorkbookEnforcesMaxRows(t *testing.T) {
+	txtFile := writeTestTxt(t, t.TempDir(), "rows.txt", splitRecords(3)...)
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	// 每個工作表各自限制筆數：第 2 個工作表超過上限時匯出失敗，不留下檔案
+	outputFolder := t.TempDir()
+	allocation := [][]*TxtFileInfo{{}, fileInfoList}
+	if _, err := ExportToSingleWorkbook(ctx, allocation, outputFolder, 2, nil, DefaultValidationOptions(), nil); err == nil {
+		t.Fatal("工作表超過最大筆數應回傳錯誤")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(outputFolder, "*.xlsx")); len(matches) != 0 {
+		t.Errorf("產出 %d 個 Excel，應為 0 個", len(matches))
+	}
+}
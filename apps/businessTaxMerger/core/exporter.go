@@ -0,0 +1,209 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Exporter 輸出格式：將分配結果匯出到輸出資料夾，並回傳驗證報告
+// ctx 取消或失敗時停止匯出，寫到一半的檔案會被刪除
+type Exporter interface {
+	// Name 輸出格式名稱
+	Name() string
+	// Export 匯出分配結果
+	Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error)
+}
+
+// ExcelExporter 每個分配各產出一個 Excel
+type ExcelExporter struct {
+	Profile        *ColumnProfile    // 欄位設定，nil 時使用預設欄位設定
+	MaxRows        int               // 每個 Excel 的最大資料筆數，<= 0 時為 MaxExcelDataRows
+	Workers        int               // 並行產出的檔案數，<= 0 表示使用 DefaultWorkers
+	Validation     ValidationOptions // 驗證選項
+	OmitProvenance bool              // 不附加來源檔案與行號欄位，完全依欄位設定（預設在欄位設定後附加）
+	Periods        *PeriodReport     // AnalyzePeriods 的結果，nil 時匯出時再讀取計算
+}
+
+// Name 輸出格式名稱
+func (e *ExcelExporter) Name() string { return "Excel" }
+
+// Export 匯出分配結果
+func (e *ExcelExporter) Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error) {
+	return ExportToExcel(ctx, allocation, outputFolder, e.MaxRows, e.Workers, exportProfile(e.Profile, !e.OmitProvenance), e.Validation, e.Periods)
+}
+
+// WorkbookExporter 所有分配匯出到單一 Excel，每個分配一個工作表
+type WorkbookExporter struct {
+	Profile        *ColumnProfile    // 欄位設定，nil 時使用預設欄位設定
+	MaxRows        int               // 每個工作表的最大資料筆數，<= 0 時為 MaxExcelDataRows
+	Validation     ValidationOptions // 驗證選項
+	OmitProvenance bool              // 不附加來源檔案與行號欄位，完全依欄位設定（預設在欄位設定後附加）
+	Periods        *PeriodReport     // AnalyzePeriods 的結果，nil 時匯出時再讀取計算
+}
+
+// Name 輸出格式名稱
+func (e *WorkbookExporter) Name() string { return "單一 Excel" }
+
+// Export 匯出分配結果
+func (e *WorkbookExporter) Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error) {
+	return ExportToSingleWorkbook(ctx, allocation, outputFolder, e.MaxRows, exportProfile(e.Profile, !e.OmitProvenance), e.Validation, e.Periods)
+}
+
+// TxtExporter 合併為單一媒體申報 TXT（固定長度格式，無法加入來源欄位）
+type TxtExporter struct {
+	Renumber   bool              // 依申報營業人重新編列流水號
+	Validation ValidationOptions // 驗證選項
+}
+
+// Name 輸出格式名稱
+func (e *TxtExporter) Name() string { return "申報 TXT" }
+
+// Export 匯出分配結果
+func (e *TxtExporter) Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error) {
+	return MergeToTxt(ctx, allocation, outputFolder, e.Renumber, e.Validation)
+}
+
+// exportProfile 匯出使用的欄位設定：nil 時為預設欄位設定，provenance 為 true 時再補上來源欄位
+// provenance 為 false 時完全依欄位設定，不另加欄位
+func exportProfile(profile *ColumnProfile, provenance bool) *ColumnProfile {
+	if profile == nil {
+		profile = DefaultColumnProfile
+	}
+	if !provenance {
+		return profile
+	}
+	return withProvenance(profile)
+}
+
+// withProvenance 欄位設定缺少來源檔案或行號時補在最後，讓每筆輸出資料都能追溯到原始 TXT
+func withProvenance(profile *ColumnProfile) *ColumnProfile {
+	hasField := func(field string) bool {
+		for _, column := range profile.Columns {
+			if column.Field == field {
+				return true
+			}
+		}
+		return false
+	}
+	if hasField("SourceFileName") && hasField("LineNumber") {
+		return profile
+	}
+
+	columns := append([]ExportColumn{}, profile.Columns...)
+	if !hasField("SourceFileName") {
+		columns = append(columns, ExportColumn{Field: "SourceFileName", Width: 30})
+	}
+	if !hasField("LineNumber") {
+		columns = append(columns, ExportColumn{Field: "LineNumber", Width: 10})
+	}
+	return &ColumnProfile{Name: profile.Name, Columns: columns}
+}
+
+// recordEncoder 逐筆寫出資料的文字格式（CSV、JSON Lines）
+type recordEncoder interface {
+	// writeHeader 寫入標題（沒有標題的格式不做任何事）
+	writeHeader() error
+	// writeRecord 寫入一筆資料，回傳寫入時需調整內容的問題（例如 Big5 無法表示的字元）
+	writeRecord(record *TaxRecord) ([]ValidationIssue, error)
+	// close 寫出緩衝的內容
+	close() error
+}
+
+// exportRecordFiles 每個分配各產出一個文字檔（副檔名 ext），以 newEncoder 逐筆寫出資料並回傳驗證報告
+// 各檔案以 workers 個 worker 並行產出，檔案編號固定依分配順序；ctx 取消或失敗時刪除寫到一半的檔案
+func exportRecordFiles(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string, ext string, workers int, options ValidationOptions, newEncoder func(w io.Writer) recordEncoder) (*ValidationReport, error) {
+	timestamp := time.Now().Format("20060102_150405")
+	fileReports := make([]*ValidationReport, len(allocation))
+
+	// 並行時避免各檔案的進度訊息交錯
+	var printMu sync.Mutex
+
+	err := runWorkers(ctx, workers, len(allocation), func(ctx context.Context, i int) error {
+		fileName := allocationFileName(allocation[i], i+1, timestamp, ext)
+		fullPath := filepath.Join(outputFolder, fileName)
+
+		fileReport, recordCount, err := writeRecordFile(ctx, fullPath, allocation[i], options, newEncoder)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("產生檔案 %s 失敗: %v", fileName, err)
+		}
+		fileReports[i] = fileReport
+
+		printMu.Lock()
+		defer printMu.Unlock()
+		fmt.Printf("  ✓ 已產出第 %d 個檔案: %s（%d 筆資料）\n", i+1, fileName, recordCount)
+		return nil
+	})
+
+	// 依檔案編號順序合併報告
+	report := &ValidationReport{}
+	for _, fileReport := range fileReports {
+		if fileReport != nil {
+			report.Merge(fileReport)
+		}
+	}
+
+	return report, err
+}
+
+// writeRecordFile 逐檔讀取暫存資料寫入一個文字檔，回傳驗證報告與寫入筆數
+// 失敗或 ctx 取消時刪除寫到一半的檔案
+func writeRecordFile(ctx context.Context, filePath string, fileGroup []*TxtFileInfo, options ValidationOptions, newEncoder func(w io.Writer) recordEncoder) (report *ValidationReport, recordCount int, err error) {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			os.Remove(filePath)
+		}
+	}()
+
+	writer := bufio.NewWriter(file)
+	encoder := newEncoder(writer)
+	if err := encoder.writeHeader(); err != nil {
+		return nil, 0, err
+	}
+
+	report = &ValidationReport{}
+	for _, fileInfo := range fileGroup {
+		err := fileInfo.EachRecord(func(record *TaxRecord) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			report.Add(ValidateRecord(record, options)...)
+			checks := fileInfo.crossRecordChecks(record.LineNumber)
+			report.Add(checks.issues...)
+			if checks.duplicate != nil {
+				report.Add(checks.duplicate.issue(record.SourceFileName, record.LineNumber))
+			}
+			recordCount++
+			issues, err := encoder.writeRecord(record)
+			report.Add(issues...)
+			return err
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("寫入檔案 %s 的資料失敗: %v", fileInfo.FileName, err)
+		}
+		report.AddParseErrors(fileInfo.ParseErrors...)
+	}
+
+	if err := encoder.close(); err != nil {
+		return nil, 0, err
+	}
+	if err := writer.Flush(); err != nil {
+		return nil, 0, err
+	}
+	return report, recordCount, nil
+}
@@ -1,49 +1,54 @@
 package core
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"os"
 	"sync"
 )
 
-// AnalyzeFiles 分析所有 TXT 檔案的行數（並行處理）
-func AnalyzeFiles(txtFiles []string) ([]*TxtFileInfo, error) {
+// AnalyzeFiles 分析所有 TXT 檔案（以 workers 個 worker 並行處理，<= 0 表示使用 DefaultWorkers）
+// 每個檔案只讀取一次：解析後的有效資料存入暫存檔供匯出使用，處理完畢後需呼叫 ReleaseFiles 刪除
+// ctx 取消時停止分析、刪除已產生的暫存檔並回傳 ctx 的錯誤
+func AnalyzeFiles(ctx context.Context, txtFiles []string, workers int) ([]*TxtFileInfo, error) {
 	fileInfoList := make([]*TxtFileInfo, len(txtFiles))
 
-	// 使用 WaitGroup 進行並行處理
-	var wg sync.WaitGroup
 	var mu sync.Mutex
-	errors := make([]error, 0)
+	failures := make([]error, 0)
 
 	fmt.Println("正在分析檔案...")
 	fmt.Println()
 
-	for i, file := range txtFiles {
-		wg.Add(1)
-		// 並行處理
-		go func(index int, filePath string) {
-			defer wg.Done() // 函式結束時自動執行
-
-			lineCount, err := countFileLines(filePath)
-			if err != nil {
-				mu.Lock()
-				errors = append(errors, fmt.Errorf("檔案 %s 讀取失敗: %v", filePath, err))
-				mu.Unlock()
-				return
-			}
+	err := runWorkers(ctx, workers, len(txtFiles), func(ctx context.Context, index int) error {
+		filePath := txtFiles[index]
 
+		info, err := analyzeFile(ctx, filePath)
+		if err != nil {
+			// 取消時中止全部分析，其餘錯誤只略過該檔案
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
 			mu.Lock()
-			fileInfoList[index] = NewTxtFileInfo(filePath, lineCount)
+			failures = append(failures, fmt.Errorf("檔案 %s 讀取失敗: %v", filePath, err))
 			mu.Unlock()
-		}(i, file)
-	}
+			return nil
+		}
 
-	wg.Wait()
+		// 每個 worker 寫入不同的位置，輸出順序與輸入一致
+		fileInfoList[index] = info
+		return nil
+	})
+	if err != nil {
+		for _, info := range fileInfoList {
+			if info != nil {
+				info.Release()
+			}
+		}
+		return nil, err
+	}
 
-	if len(errors) > 0 {
+	if len(failures) > 0 {
 		fmt.Println("警告: 部分檔案讀取失敗")
-		for _, err := range errors {
+		for _, err := range failures {
 			fmt.Printf("  - %v\n", err)
 		}
 	}
@@ -57,71 +62,74 @@ func AnalyzeFiles(txtFiles []string) ([]*TxtFileInfo, error) {
 	}
 
 	totalLines := 0
+	totalRecords := 0
+	totalParseErrors := 0
 	for _, f := range validFiles {
 		totalLines += f.LineCount
+		totalRecords += f.RecordCount
+		totalParseErrors += len(f.ParseErrors)
 	}
 
-	fmt.Printf("總計：%d 個檔案，共 %d 行\n", len(validFiles), totalLines)
+	fmt.Printf("總計：%d 個檔案，共 %d 行，有效資料 %d 筆\n", len(validFiles), totalLines, totalRecords)
+	if totalParseErrors > 0 {
+		fmt.Printf("⚠ %d 個結構錯誤的資料行將不會匯出\n", totalParseErrors)
+	}
 
 	return validFiles, nil
 }
 
-// countFileLines 計算檔案行數
-func countFileLines(filePath string) (int, error) {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return 0, err
+// ValidateAndAllocateFiles 驗證並依指定策略分配檔案到 Excel 檔案中
+// splitOversized 為 true 時，超過最大列數的單一檔案會以資料筆為界拆分到連續的 Excel；否則視為錯誤
+func ValidateAndAllocateFiles(
+	ctx context.Context,
+	fileInfoList []*TxtFileInfo,
+	maxRowsPerExcel int,
+	desiredExcelCount int,
+	strategy AllocationStrategy,
+	splitOversized bool,
+) ([][]*TxtFileInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
-	defer file.Close()
 
-	scanner := bufio.NewScanner(file)
-	count := 0
-	for scanner.Scan() {
-		count++
+	// 依策略分配檔案到 Excel
+	allocation, err := allocateFiles(strategy, fileInfoList, maxRowsPerExcel, desiredExcelCount, splitOversized)
+	if err != nil {
+		return nil, err
 	}
 
-	if err := scanner.Err(); err != nil {
-		return 0, err
+	if err := checkAllocation(allocation, desiredExcelCount, strategy); err != nil {
+		return nil, err
 	}
 
-	return count, nil
-}
-
-// ValidateAndAllocateFiles 驗證並分配檔案到 Excel 檔案中
-func ValidateAndAllocateFiles(
-	fileInfoList []*TxtFileInfo,
-	maxRowsPerExcel int,
-	desiredExcelCount int,
-) ([][]*TxtFileInfo, error) {
-	// 檢查是否有任何單一檔案超過最大列數限制
-	for _, fileInfo := range fileInfoList {
-		if fileInfo.LineCount > maxRowsPerExcel {
-			return nil, fmt.Errorf(
-				"檔案 '%s' 有 %d 行，超過單一 Excel 最大列數限制 %d 行，此檔案無法處理",
-				fileInfo.FileName,
-				fileInfo.LineCount,
-				maxRowsPerExcel,
-			)
-		}
+	// 補上拆分片段的行號範圍
+	if err := resolveFileParts(allocation); err != nil {
+		return nil, err
 	}
 
-	// 模擬分配檔案到 Excel
-	allocation := simulateFileAllocation(fileInfoList, maxRowsPerExcel)
+	return allocation, nil
+}
 
-	// 檢查是否超過使用者期望的檔案個數
+// checkAllocation 檢查分配結果是否超過使用者期望的檔案個數
+// 建議依目前策略決定：已是「最少檔案數」或「平均分配」時不再建議改用「最少檔案數」
+func checkAllocation(allocation [][]*TxtFileInfo, desiredExcelCount int, strategy AllocationStrategy) error {
 	if len(allocation) > desiredExcelCount {
-		return nil, fmt.Errorf(
-			"根據檔案大小和不可分割規則，需要至少 %d 個 Excel 檔案才能容納所有資料，但使用者只設定了 %d 個檔案。\n建議：增加檔案個數至 %d 個或更多，或增加每個 Excel 的最大列數限制",
+		suggestion := fmt.Sprintf("增加檔案個數至 %d 個或更多，或增加每個 Excel 的最大列數限制", len(allocation))
+		if strategy == StrategyNextFit {
+			suggestion = fmt.Sprintf("增加檔案個數至 %d 個或更多、改用「%s」策略，或增加每個 Excel 的最大列數限制", len(allocation), StrategyFirstFitDecreasing)
+		}
+		return fmt.Errorf(
+			"根據檔案大小和不可分割規則，「%s」策略需要至少 %d 個 Excel 檔案才能容納所有資料，但使用者只設定了 %d 個檔案。\n建議：%s",
+			strategy,
 			len(allocation),
 			desiredExcelCount,
-			len(allocation),
+			suggestion,
 		)
 	}
-
-	return allocation, nil
+	return nil
 }
 
-// simulateFileAllocation 模擬檔案分配到 Excel
+// simulateFileAllocation 模擬檔案分配到 Excel（依序填滿）
 // 規則：一個檔案不可分割，如果加上該檔案會超出最大限制，就分配到下一個 Excel
 func simulateFileAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int) [][]*TxtFileInfo {
 	allocation := make([][]*TxtFileInfo, 0)
@@ -130,7 +138,7 @@ func simulateFileAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int) []
 
 	for _, fileInfo := range fileInfoList {
 		// 如果加入當前檔案會超過限制，則開啟新的 Excel
-		if currentRowCount+fileInfo.LineCount > maxRowsPerExcel && len(currentExcel) > 0 {
+		if currentRowCount+fileInfo.RecordCount > maxRowsPerExcel && len(currentExcel) > 0 {
 			allocation = append(allocation, currentExcel)
 			currentExcel = make([]*TxtFileInfo, 0)
 			currentRowCount = 0
@@ -138,7 +146,7 @@ func simulateFileAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int) []
 
 		// 將檔案加入當前 Excel
 		currentExcel = append(currentExcel, fileInfo)
-		currentRowCount += fileInfo.LineCount
+		currentRowCount += fileInfo.RecordCount
 	}
 
 	// 加入最後一個 Excel（如果有內容）
@@ -159,16 +167,24 @@ func DisplayAllocation(allocation [][]*TxtFileInfo, maxRowsPerExcel int) {
 	for i, excelFiles := range allocation {
 		totalRows := 0
 		for _, f := range excelFiles {
-			totalRows += f.LineCount
+			totalRows += f.RecordCount
 		}
 		percentage := float64(totalRows) / float64(maxRowsPerExcel) * 100
 
 		fmt.Println()
-		fmt.Printf("Excel 檔案 #%d：\n", i+1)
-		fmt.Printf("  包含 %d 個 TXT 檔案，共 %d 行 (%.2f%%)\n", len(excelFiles), totalRows, percentage)
+		if len(excelFiles) > 0 && excelFiles[0].GroupBy != GroupByNone {
+			fmt.Printf("Excel 檔案 #%d（%s）：\n", i+1, excelFiles[0].GroupLabel())
+		} else {
+			fmt.Printf("Excel 檔案 #%d：\n", i+1)
+		}
+		fmt.Printf("  包含 %d 個 TXT 檔案，共 %d 筆資料 (%.2f%%)\n", len(excelFiles), totalRows, percentage)
 
 		for _, file := range excelFiles {
-			fmt.Printf("    - %s: %d 行\n", file.FileName, file.LineCount)
+			if file.IsPart() {
+				fmt.Printf("    - %s [%s]: %d 筆 (%s)\n", file.FileName, file.PartDescription(), file.RecordCount, file.Encoding)
+				continue
+			}
+			fmt.Printf("    - %s: %d 筆 (%s)\n", file.FileName, file.RecordCount, file.Encoding)
 		}
 	}
 
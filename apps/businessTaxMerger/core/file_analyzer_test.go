@@ -0,0 +1,34 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckAllocationSuggestion(t *testing.T) {
+	allocation := [][]*TxtFileInfo{{}, {}, {}}
+	switchHint := "改用「" + StrategyFirstFitDecreasing.String() + "」策略"
+
+	cases := []struct {
+		strategy   AllocationStrategy
+		suggestFFD bool
+	}{
+		{strategy: StrategyNextFit, suggestFFD: true},
+		{strategy: StrategyFirstFitDecreasing, suggestFFD: false},
+		{strategy: StrategyBalanced, suggestFFD: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.strategy.String(), func(t *testing.T) {
+			if err := checkAllocation(allocation, 3, tc.strategy); err != nil {
+				t.Fatalf("未超過期望個數不應回傳錯誤: %v", err)
+			}
+			err := checkAllocation(allocation, 2, tc.strategy)
+			if err == nil {
+				t.Fatal("超過期望個數應回傳錯誤")
+			}
+			if got := strings.Contains(err.Error(), switchHint); got != tc.suggestFFD {
+				t.Errorf("建議改用「%s」= %v，應為 %v：%v", StrategyFirstFitDecreasing, got, tc.suggestFFD, err)
+			}
+		})
+	}
+}
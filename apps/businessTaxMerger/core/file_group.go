@@ -0,0 +1,228 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+)
+
+// GroupBy 分組依據（每組資料各自產出 Excel）
+type GroupBy int
+
+const (
+	// GroupByNone 不分組
+	GroupByNone GroupBy = iota
+	// GroupByDeclarant 依申報營業人稅籍編號
+	GroupByDeclarant
+	// GroupByPeriod 依資料所屬年度 + 月份
+	GroupByPeriod
+	// GroupByFormatCode 依格式代號
+	GroupByFormatCode
+)
+
+// GroupByOptions 所有可選擇的分組依據（依選單順序）
+var GroupByOptions = []GroupBy{
+	GroupByNone,
+	GroupByDeclarant,
+	GroupByPeriod,
+	GroupByFormatCode,
+}
+
+// String 分組依據名稱
+func (g GroupBy) String() string {
+	switch g {
+	case GroupByNone:
+		return "不分組"
+	case GroupByDeclarant:
+		return "申報營業人"
+	case GroupByPeriod:
+		return "資料所屬年月"
+	case GroupByFormatCode:
+		return "格式代號"
+	default:
+		return "未知分組"
+	}
+}
+
+// groupValue 取得資料的分組值（用於檔名，只含數字與英文）
+func (g GroupBy) groupValue(record *TaxRecord) string {
+	switch g {
+	case GroupByDeclarant:
+		return record.DeclarantTaxId
+	case GroupByPeriod:
+		return record.DataYear + record.DataMonth
+	case GroupByFormatCode:
+		return record.FormatCode
+	default:
+		return ""
+	}
+}
+
+// GroupLabel 分組說明，例如「申報營業人 123456789」；未分組時回傳空字串
+func (info *TxtFileInfo) GroupLabel() string {
+	if info.GroupBy == GroupByNone {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", info.GroupBy, info.GroupValue)
+}
+
+// GroupFiles 依分組依據將每個檔案的資料拆成「檔案 × 組」，每組各自一個暫存檔
+// 回傳的清單依組別、再依原檔案順序排列；分配時同一組的資料只會放在同一組的 Excel
+// 原清單的暫存檔不會刪除，呼叫端可在分組後自行 ReleaseFiles
+func GroupFiles(ctx context.Context, fileInfoList []*TxtFileInfo, groupBy GroupBy, workers int) ([]*TxtFileInfo, error) {
+	if groupBy == GroupByNone {
+		return fileInfoList, nil
+	}
+
+	fmt.Printf("正在依%s分組...\n", groupBy)
+
+	groupedByFile := make([][]*TxtFileInfo, len(fileInfoList))
+	var mu sync.Mutex
+
+	err := runWorkers(ctx, workers, len(fileInfoList), func(ctx context.Context, index int) error {
+		groups, err := groupFile(ctx, fileInfoList[index], groupBy)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return fmt.Errorf("檔案 %s 分組失敗: %v", fileInfoList[index].FileName, err)
+		}
+		mu.Lock()
+		groupedByFile[index] = groups
+		mu.Unlock()
+		return nil
+	})
+
+	grouped := make([]*TxtFileInfo, 0)
+	for _, groups := range groupedByFile {
+		grouped = append(grouped, groups...)
+	}
+	if err != nil {
+		ReleaseFiles(grouped)
+		return nil, err
+	}
+
+	// 依組別排序，同組維持原檔案順序
+	sort.SliceStable(grouped, func(a, b int) bool {
+		return grouped[a].GroupValue < grouped[b].GroupValue
+	})
+
+	groupCount := 0
+	for i, info := range grouped {
+		if i == 0 || info.GroupValue != grouped[i-1].GroupValue {
+			groupCount++
+		}
+	}
+	fmt.Printf("✓ 共 %d 組\n", groupCount)
+
+	return grouped, nil
+}
+
+// groupFile 將單一檔案的資料依組別寫入各自的暫存檔
+// 結構錯誤歸到第一組，避免在多個 Excel 重複列出
+func groupFile(ctx context.Context, fileInfo *TxtFileInfo, groupBy GroupBy) ([]*TxtFileInfo, error) {
+	type groupSpill struct {
+		info   *TxtFileInfo
+		file   *os.File
+		writer *bufio.Writer
+	}
+	spills := make(map[string]*groupSpill)
+	order := make([]string, 0)
+
+	closeAll := func() error {
+		var firstErr error
+		for _, value := range order {
+			spill := spills[value]
+			if err := spill.writer.Flush(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			if err := spill.file.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+
+	err := fileInfo.EachRecord(func(record *TaxRecord) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		value := groupBy.groupValue(record)
+		spill, ok := spills[value]
+		if !ok {
+			file, err := os.CreateTemp("", "accountingTools_*.spill")
+			if err != nil {
+				return fmt.Errorf("建立暫存檔失敗: %v", err)
+			}
+			info := NewTxtFileInfo(fileInfo.FilePath, fileInfo.LineCount, fileInfo.Encoding)
+			info.SpillPath = file.Name()
+			info.GroupBy = groupBy
+			info.GroupValue = value
+			info.StartLine = record.LineNumber
+			spill = &groupSpill{info: info, file: file, writer: bufio.NewWriter(file)}
+			spills[value] = spill
+			order = append(order, value)
+		}
+
+		// 暫存格式與 analyzeFile 相同：行號<TAB>原始資料（直接寫回原始資料行，分組不改變資料內容）
+		if _, err := fmt.Fprintf(spill.writer, "%d\t%s\n", record.LineNumber, record.RawData); err != nil {
+			return err
+		}
+		spill.info.RecordCount++
+		spill.info.EndLine = record.LineNumber
+		spill.info.analyzed.add(record)
+		return nil
+	})
+	if closeErr := closeAll(); err == nil {
+		err = closeErr
+	}
+
+	groups := make([]*TxtFileInfo, 0, len(order))
+	var total recordTotal
+	for _, value := range order {
+		groups = append(groups, spills[value].info)
+		total.merge(spills[value].info.analyzed)
+	}
+	// 各組合計須與分析時記錄的相符，否則暫存檔已變動
+	if err == nil && total != fileInfo.analyzed {
+		err = fmt.Errorf("暫存資料的筆數或金額與分析時不符")
+	}
+	if err != nil {
+		ReleaseFiles(groups)
+		return nil, err
+	}
+
+	// 沒有有效資料但有結構錯誤時，仍保留一個空的組別以便列出錯誤
+	if len(groups) == 0 && len(fileInfo.ParseErrors) > 0 {
+		file, err := os.CreateTemp("", "accountingTools_*.spill")
+		if err != nil {
+			return nil, fmt.Errorf("建立暫存檔失敗: %v", err)
+		}
+		file.Close()
+		info := NewTxtFileInfo(fileInfo.FilePath, fileInfo.LineCount, fileInfo.Encoding)
+		info.SpillPath = file.Name()
+		info.GroupBy = groupBy
+		groups = append(groups, info)
+	}
+
+	if len(groups) > 0 {
+		groups[0].ParseErrors = fileInfo.ParseErrors
+	}
+	return groups, nil
+}
+
+// partitionByGroup 將檔案清單依組別切開（清單須已依組別排序）
+func partitionByGroup(fileInfoList []*TxtFileInfo) [][]*TxtFileInfo {
+	partitions := make([][]*TxtFileInfo, 0)
+	for i, info := range fileInfoList {
+		if i == 0 || info.GroupValue != fileInfoList[i-1].GroupValue {
+			partitions = append(partitions, make([]*TxtFileInfo, 0))
+		}
+		partitions[len(partitions)-1] = append(partitions[len(partitions)-1], info)
+	}
+	return partitions
+}
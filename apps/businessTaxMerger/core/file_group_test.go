@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGroupFilesKeepsRawLines(t *testing.T) {
+	lines := make([]string, 0, len(roundTripCases)+1)
+	for _, tc := range roundTripCases {
+		lines = append(lines, tc.line)
+	}
+	// 無法以 Big5 表示的字元：FormatRecord 會轉為 ??，分組後仍應保留原字元
+	lines = append(lines, "35"+"123456789"+"0000009"+"113"+"02"+"        "+"22099131"+"GH"+"00000002"+"000000000100"+"1"+"0000000005"+" "+"한註 "+" "+" "+" ")
+	txtFile := filepath.Join(t.TempDir(), "group.txt")
+	if err := os.WriteFile(txtFile, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	grouped, err := GroupFiles(ctx, fileInfoList, GroupByFormatCode, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(grouped)
+
+	count := 0
+	for _, info := range grouped {
+		err := info.EachRecord(func(record *TaxRecord) error {
+			count++
+			if want := lines[record.LineNumber-1]; record.RawData != want {
+				t.Errorf("第 %d 行分組後 = %q，應為 %q", record.LineNumber, record.RawData, want)
+			}
+			if record.FormatCode != info.GroupValue {
+				t.Errorf("第 %d 行格式代號 %s 分到 %s 組", record.LineNumber, record.FormatCode, info.GroupValue)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if count != len(lines) {
+		t.Errorf("分組後共 %d 筆，應為 %d 筆", count, len(lines))
+	}
+}
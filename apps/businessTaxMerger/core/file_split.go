@@ -0,0 +1,121 @@
+package core
+
+import (
+	"fmt"
+)
+
+// IsPart 是否為拆分後的片段
+func (info *TxtFileInfo) IsPart() bool {
+	return info.PartCount > 0
+}
+
+// PartDescription 片段說明，例如「第 2/3 段，第 1048577–2000000 行」；未拆分時回傳空字串
+func (info *TxtFileInfo) PartDescription() string {
+	if !info.IsPart() {
+		return ""
+	}
+	return fmt.Sprintf("第 %d/%d 段，第 %d–%d 行", info.PartNumber, info.PartCount, info.StartLine, info.EndLine)
+}
+
+// splitFileAllocation 依序填滿並拆分超過最大列數的檔案
+// 規則：一般檔案與依序填滿相同；超過最大列數的檔案先填滿目前 Excel 的剩餘空間，
+// 其餘資料以資料筆為界依序放入後續連續的 Excel
+func splitFileAllocation(fileInfoList []*TxtFileInfo, maxRowsPerExcel int) [][]*TxtFileInfo {
+	allocation := make([][]*TxtFileInfo, 0)
+	currentExcel := make([]*TxtFileInfo, 0)
+	currentRowCount := 0
+
+	for _, fileInfo := range fileInfoList {
+		// 未超過最大列數的檔案不拆分
+		if fileInfo.RecordCount <= maxRowsPerExcel {
+			if currentRowCount+fileInfo.RecordCount > maxRowsPerExcel && len(currentExcel) > 0 {
+				allocation = append(allocation, currentExcel)
+				currentExcel = make([]*TxtFileInfo, 0)
+				currentRowCount = 0
+			}
+			currentExcel = append(currentExcel, fileInfo)
+			currentRowCount += fileInfo.RecordCount
+			continue
+		}
+
+		for offset := 0; offset < fileInfo.RecordCount; {
+			// 目前 Excel 已滿則開啟新的 Excel
+			if currentRowCount == maxRowsPerExcel {
+				allocation = append(allocation, currentExcel)
+				currentExcel = make([]*TxtFileInfo, 0)
+				currentRowCount = 0
+			}
+
+			count := maxRowsPerExcel - currentRowCount
+			if remaining := fileInfo.RecordCount - offset; remaining < count {
+				count = remaining
+			}
+
+			currentExcel = append(currentExcel, newFilePart(fileInfo, offset, count))
+			currentRowCount += count
+			offset += count
+		}
+	}
+
+	// 加入最後一個 Excel（如果有內容）
+	if len(currentExcel) > 0 {
+		allocation = append(allocation, currentExcel)
+	}
+
+	return allocation
+}
+
+// newFilePart 建立原始檔的片段（序號與行號範圍由 resolveFileParts 補上）
+func newFilePart(source *TxtFileInfo, offset int, count int) *TxtFileInfo {
+	part := *source
+	part.RecordOffset = offset
+	part.RecordCount = count
+	part.ParseErrors = nil
+	part.analyzed = recordTotal{}
+	part.source = source
+	return &part
+}
+
+// resolveFileParts 補上分配結果中各片段的序號、行號範圍與筆數金額，並將結構錯誤分到所在行號的片段
+func resolveFileParts(allocation [][]*TxtFileInfo) error {
+	// 依原始檔整理片段（維持分配順序）
+	sources := make([]*TxtFileInfo, 0)
+	partsBySource := make(map[*TxtFileInfo][]*TxtFileInfo)
+	for _, excelFiles := range allocation {
+		for _, fileInfo := range excelFiles {
+			if fileInfo.source == nil {
+				continue
+			}
+			if _, ok := partsBySource[fileInfo.source]; !ok {
+				sources = append(sources, fileInfo.source)
+			}
+			partsBySource[fileInfo.source] = append(partsBySource[fileInfo.source], fileInfo)
+		}
+	}
+
+	for _, source := range sources {
+		parts := partsBySource[source]
+
+		if err := source.measureParts(parts); err != nil {
+			return fmt.Errorf("檔案 %s 拆分失敗: %v", source.FileName, err)
+		}
+		for i, part := range parts {
+			part.PartNumber = i + 1
+			part.PartCount = len(parts)
+		}
+
+		// 結構錯誤歸到行號所在的片段（片段之間的錯誤行歸前一個片段）
+		for _, parseError := range source.ParseErrors {
+			target := parts[0]
+			for _, part := range parts[1:] {
+				if parseError.LineNumber < part.StartLine {
+					break
+				}
+				target = part
+			}
+			target.ParseErrors = append(target.ParseErrors, parseError)
+		}
+	}
+
+	return nil
+}
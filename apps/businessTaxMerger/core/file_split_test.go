@@ -0,0 +1,220 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// allocationCounts 分配結果中各 Excel 各檔案（片段）的筆數
+func allocationCounts(allocation [][]*TxtFileInfo) [][]int {
+	counts := make([][]int, len(allocation))
+	for i, excelFiles := range allocation {
+		for _, fileInfo := range excelFiles {
+			counts[i] = append(counts[i], fileInfo.RecordCount)
+		}
+	}
+	return counts
+}
+
+func TestAllocateFilesExcelRowLimit(t *testing.T) {
+	cases := []struct {
+		name    string
+		maxRows int
+		records int
+		split   bool
+		// want 各 Excel 的筆數（nil 表示應回傳錯誤）
+		want [][]int
+	}{
+		{name: "最大列數含標題列時扣除標題列拆分", maxRows: excelize.TotalRows, records: excelize.TotalRows + 5, split: true, want: [][]int{{MaxExcelDataRows}, {6}}},
+		{name: "剛好填滿一個工作表", maxRows: excelize.TotalRows, records: MaxExcelDataRows, want: [][]int{{MaxExcelDataRows}}},
+		{name: "多一筆時不拆分視為錯誤", maxRows: excelize.TotalRows, records: excelize.TotalRows},
+		{name: "多一筆時拆分", maxRows: excelize.TotalRows, records: excelize.TotalRows, split: true, want: [][]int{{MaxExcelDataRows}, {1}}},
+		{name: "未超過上限時依設定", maxRows: 1000, records: 2500, split: true, want: [][]int{{1000}, {1000}, {500}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			big := &TxtFileInfo{FileName: "big.txt", RecordCount: tc.records}
+			allocation, err := allocateFiles(StrategyNextFit, []*TxtFileInfo{big}, tc.maxRows, 10, tc.split)
+			if tc.want == nil {
+				if err == nil {
+					t.Fatalf("應回傳錯誤，實際分配為 %v", allocationCounts(allocation))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := allocationCounts(allocation); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("各 Excel 筆數 = %v，應為 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// splitRecords count 筆金額各不相同的進項發票（比對片段合計時可看出漏算或重複）
+func splitRecords(count int) []TaxRecord {
+	records := make([]TaxRecord, count)
+	for i := range records {
+		records[i] = purchaseRecord("21", "1", "", fmt.Sprint(100*(i+1)), fmt.Sprint(5*(i+1)))
+		records[i].SequenceNumber = fmt.Sprintf("%07d", i+1)
+		records[i].InvoiceStartNumber = fmt.Sprintf("%08d", i+1)
+	}
+	return records
+}
+
+// describeAllocation 各 Excel 的內容：檔名（片段另附片段說明）與筆數
+func describeAllocation(allocation [][]*TxtFileInfo) [][]string {
+	got := make([][]string, len(allocation))
+	for i, excelFiles := range allocation {
+		for _, fileInfo := range excelFiles {
+			name := fileInfo.FileName
+			if fileInfo.IsPart() {
+				name += " " + fileInfo.PartDescription()
+			}
+			got[i] = append(got[i], fmt.Sprintf("%s：%d 筆", name, fileInfo.RecordCount))
+		}
+	}
+	return got
+}
+
+func TestSplitFileAllocation(t *testing.T) {
+	type file struct {
+		name    string
+		records int
+	}
+	cases := []struct {
+		name    string
+		files   []file
+		maxRows int
+		want    [][]string
+	}{
+		{
+			name:    "最後一段未填滿",
+			files:   []file{{"big.txt", 7}},
+			maxRows: 3,
+			want: [][]string{
+				{"big.txt 第 1/3 段，第 1–3 行：3 筆"},
+				{"big.txt 第 2/3 段，第 4–6 行：3 筆"},
+				{"big.txt 第 3/3 段，第 7–7 行：1 筆"},
+			},
+		},
+		{
+			name:    "剛好填滿時不產生空片段",
+			files:   []file{{"big.txt", 6}},
+			maxRows: 3,
+			want: [][]string{
+				{"big.txt 第 1/2 段，第 1–3 行：3 筆"},
+				{"big.txt 第 2/2 段，第 4–6 行：3 筆"},
+			},
+		},
+		{
+			name:    "與一般檔案混合",
+			files:   []file{{"a.txt", 2}, {"big.txt", 5}, {"c.txt", 1}, {"d.txt", 2}},
+			maxRows: 3,
+			want: [][]string{
+				{"a.txt：2 筆", "big.txt 第 1/3 段，第 1–1 行：1 筆"},
+				{"big.txt 第 2/3 段，第 2–4 行：3 筆"},
+				{"big.txt 第 3/3 段，第 5–5 行：1 筆", "c.txt：1 筆"},
+				{"d.txt：2 筆"},
+			},
+		},
+		{
+			name:    "兩個大檔案相鄰",
+			files:   []file{{"a.txt", 4}, {"b.txt", 4}},
+			maxRows: 3,
+			want: [][]string{
+				{"a.txt 第 1/2 段，第 1–3 行：3 筆"},
+				{"a.txt 第 2/2 段，第 4–4 行：1 筆", "b.txt 第 1/2 段，第 1–2 行：2 筆"},
+				{"b.txt 第 2/2 段，第 3–4 行：2 筆"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			paths := make([]string, len(tc.files))
+			for i, f := range tc.files {
+				paths[i] = writeTestTxt(t, dir, f.name, splitRecords(f.records)...)
+			}
+			ctx := context.Background()
+			fileInfoList, err := AnalyzeFiles(ctx, paths, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ReleaseFiles(fileInfoList)
+
+			allocation, err := ValidateAndAllocateFiles(ctx, fileInfoList, tc.maxRows, 10, StrategyNextFit, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := describeAllocation(allocation); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("分配結果 = %q，應為 %q", got, tc.want)
+			}
+
+			// 各片段合計與原始檔相同
+			totals := make(map[*TxtFileInfo]recordTotal)
+			for _, fileInfo := range allocationFiles(allocation) {
+				source := fileInfo
+				if fileInfo.source != nil {
+					source = fileInfo.source
+				}
+				total := totals[source]
+				total.merge(fileInfo.analyzed)
+				totals[source] = total
+			}
+			for _, source := range fileInfoList {
+				if totals[source] != source.analyzed {
+					t.Errorf("%s 各片段合計 %+v，應為 %+v", source.FileName, totals[source], source.analyzed)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitFileAllocationParseErrors(t *testing.T) {
+	// 第 3 行位於兩段之間，歸前一段；第 6 行之後沒有下一段，歸最後一段
+	records := splitRecords(4)
+	lines := []string{
+		testLine(t, records[0]),
+		testLine(t, records[1]),
+		"結構錯誤",
+		testLine(t, records[2]),
+		testLine(t, records[3]),
+		"結構錯誤",
+	}
+	txtFile := filepath.Join(t.TempDir(), "big.txt")
+	if err := os.WriteFile(txtFile, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	allocation, err := ValidateAndAllocateFiles(ctx, fileInfoList, 2, 10, StrategyNextFit, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"big.txt 第 1/2 段，第 1–2 行：2 筆"},
+		{"big.txt 第 2/2 段，第 4–5 行：2 筆"},
+	}
+	if got := describeAllocation(allocation); !reflect.DeepEqual(got, want) {
+		t.Fatalf("分配結果 = %q，應為 %q", got, want)
+	}
+	for i, wantLine := range []int{3, 6} {
+		part := allocation[i][0]
+		if len(part.ParseErrors) != 1 || part.ParseErrors[0].LineNumber != wantLine {
+			t.Errorf("第 %d 段的結構錯誤 = %v，應為第 %d 行", i+1, part.ParseErrors, wantLine)
+		}
+	}
+}
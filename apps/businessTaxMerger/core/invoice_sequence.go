@@ -0,0 +1,446 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// invoiceBookletSize 每卷（每次配號）發票張數，起號為 50 的倍數
+const invoiceBookletSize = 50
+
+// InvoiceStatus 發票號碼的使用狀態
+type InvoiceStatus int
+
+const (
+	// InvoiceIssued 開立
+	InvoiceIssued InvoiceStatus = iota
+	// InvoiceVoided 作廢（課稅別 F）
+	InvoiceVoided
+	// InvoiceBlank 空白未使用（課稅別 D）
+	InvoiceBlank
+)
+
+// String 使用狀態名稱
+func (s InvoiceStatus) String() string {
+	switch s {
+	case InvoiceIssued:
+		return "開立"
+	case InvoiceVoided:
+		return "作廢"
+	case InvoiceBlank:
+		return "空白未使用"
+	default:
+		return "未知"
+	}
+}
+
+// SequenceFindingKind 字軌號碼檢查結果類別
+type SequenceFindingKind int
+
+const (
+	// SequenceGap 缺號：前後號碼之間的號碼未申報為開立、作廢或空白
+	SequenceGap SequenceFindingKind = iota
+	// SequenceOverlap 重複：同一號碼申報超過一次（例如同時申報開立與作廢）
+	SequenceOverlap
+	// SequenceOutOfPeriod 非本期：號碼所在的卷（每 50 張）屬於同一字軌的其他期別
+	SequenceOutOfPeriod
+	// SequenceUnusedBooklet 未用完：同卷的其餘號碼未申報為空白未使用
+	SequenceUnusedBooklet
+)
+
+// String 檢查結果類別名稱
+func (k SequenceFindingKind) String() string {
+	switch k {
+	case SequenceGap:
+		return "缺號"
+	case SequenceOverlap:
+		return "重複"
+	case SequenceOutOfPeriod:
+		return "非本期"
+	case SequenceUnusedBooklet:
+		return "未用完"
+	default:
+		return "未知"
+	}
+}
+
+// SequenceFinding 字軌號碼的一項檢查結果
+type SequenceFinding struct {
+	// Kind 類別
+	Kind SequenceFindingKind
+
+	// StartNumber 起號
+	StartNumber int
+
+	// EndNumber 訖號
+	EndNumber int
+
+	// Description 說明（相關資料的來源檔案與行號）
+	Description string
+}
+
+// Count 號碼張數
+func (f SequenceFinding) Count() int {
+	return f.EndNumber - f.StartNumber + 1
+}
+
+// InvoiceTrack 申報營業人一個字軌在一個期別的號碼使用情形
+type InvoiceTrack struct {
+	// DeclarantTaxId 申報營業人稅籍編號
+	DeclarantTaxId string
+
+	// DataYear 字軌使用年度
+	DataYear string
+
+	// StartMonth、EndMonth 申報期別
+	StartMonth string
+	EndMonth   string
+
+	// InvoicePrefix 字軌
+	InvoicePrefix string
+
+	// StartNumber 最小號碼
+	StartNumber int
+
+	// EndNumber 最大號碼
+	EndNumber int
+
+	// Issued、Voided、Blank 開立、作廢、空白未使用張數
+	Issued int
+	Voided int
+	Blank  int
+
+	// Findings 檢查結果（依起號排序）
+	Findings []SequenceFinding
+
+	// spans 申報的號碼範圍
+	spans []invoiceSpan
+}
+
+// Period 期別描述，例如「113 年 01-02 月」
+func (t *InvoiceTrack) Period() string {
+	return fmt.Sprintf("%s 年 %s-%s 月", t.DataYear, t.StartMonth, t.EndMonth)
+}
+
+// SequenceReport 銷項發票字軌號碼檢查結果
+type SequenceReport struct {
+	// Tracks 各字軌，依申報營業人、年度、期別、字軌排序
+	Tracks []*InvoiceTrack
+}
+
+// forPeriods 只保留指定申報營業人與期別（returnPeriodKey）的字軌，用於各 Excel 只列出本檔案有資料的期別
+func (r *SequenceReport) forPeriods(periods map[string]bool) *SequenceReport {
+	filtered := &SequenceReport{Tracks: make([]*InvoiceTrack, 0)}
+	for _, track := range r.Tracks {
+		if periods[periodKey(track.DeclarantTaxId, track.DataYear, track.StartMonth)] {
+			filtered.Tracks = append(filtered.Tracks, track)
+		}
+	}
+	return filtered
+}
+
+// FindingCount 檢查結果總數
+func (r *SequenceReport) FindingCount() int {
+	count := 0
+	for _, track := range r.Tracks {
+		count += len(track.Findings)
+	}
+	return count
+}
+
+// invoiceSpan 一筆資料申報的號碼範圍
+type invoiceSpan struct {
+	start      int
+	end        int
+	status     InvoiceStatus
+	fileName   string
+	lineNumber int
+}
+
+// location 資料位置描述，例如「a.txt 第 3 行」
+func (s invoiceSpan) location() string {
+	return fmt.Sprintf("%s 第 %d 行", s.fileName, s.lineNumber)
+}
+
+// outputInvoiceFormatCodes 銷項統一發票的格式代號：有自己的發票號碼，可申報作廢與空白未使用
+// （退回及折讓證明單與免用發票不含在內）
+var outputInvoiceFormatCodes = map[string]bool{"31": true, "32": true, "35": true, "37": true}
+
+// outputInvoiceSpan 銷項發票資料申報的號碼範圍
+// 空白未使用（課稅別 D）以 BusinessNumber 為訖號，其餘為單張（彙加資料的位置 24-31 仍為買受人統一編號，不視為訖號）
+func outputInvoiceSpan(record *TaxRecord) (invoiceSpan, bool) {
+	if !outputInvoiceFormatCodes[record.FormatCode] || record.Kind != KindInvoice || !isInvoiceTrack(record.InvoicePrefix) {
+		return invoiceSpan{}, false
+	}
+	start, err := strconv.Atoi(record.InvoiceStartNumber)
+	if err != nil {
+		return invoiceSpan{}, false
+	}
+
+	span := invoiceSpan{
+		start:      start,
+		end:        start,
+		status:     InvoiceIssued,
+		fileName:   record.SourceFileName,
+		lineNumber: record.LineNumber,
+	}
+	switch {
+	case record.IsVoided():
+		span.status = InvoiceVoided
+	case record.IsBlank():
+		span.status = InvoiceBlank
+		if end, err := strconv.Atoi(record.BusinessNumber); err == nil && end >= start {
+			span.end = end
+		}
+	}
+	return span, true
+}
+
+// AnalyzeInvoiceSequence 讀取分配結果中的銷項發票（格式代號 31、32、35、37），
+// 依申報營業人、期別與字軌檢查號碼是否連續：每個號碼應恰好申報一次開立、作廢或空白未使用
+// 同一字軌在多個期別申報時，逐筆比對號碼所在的卷屬於哪一期，屬於其他期別的號碼列為非本期
+// 已由 AnalyzePeriods 計算過時改用 PeriodReport.Sequence，不必再讀取一次
+func AnalyzeInvoiceSequence(ctx context.Context, allocation [][]*TxtFileInfo) (*SequenceReport, error) {
+	builder := newSequenceBuilder()
+	if err := scanFiles(ctx, allocationFiles(allocation), builder.add); err != nil {
+		return nil, err
+	}
+	return builder.report(), nil
+}
+
+// sequenceBuilder 逐筆收集銷項發票的號碼範圍，全部讀取後以 report 檢查
+type sequenceBuilder struct {
+	tracks map[string]*InvoiceTrack
+}
+
+// newSequenceBuilder 建立 sequenceBuilder
+func newSequenceBuilder() *sequenceBuilder {
+	return &sequenceBuilder{tracks: make(map[string]*InvoiceTrack)}
+}
+
+// add 將一筆銷項發票的號碼範圍加入所屬申報營業人、期別與字軌
+func (b *sequenceBuilder) add(_ *TxtFileInfo, record *TaxRecord) {
+	span, ok := outputInvoiceSpan(record)
+	if !ok {
+		return
+	}
+	startMonth, endMonth := reportingPeriod(record.DataMonth)
+	key := record.DeclarantTaxId + "_" + record.DataYear + "_" + startMonth + "_" + record.InvoicePrefix
+	track, ok := b.tracks[key]
+	if !ok {
+		track = &InvoiceTrack{
+			DeclarantTaxId: record.DeclarantTaxId,
+			DataYear:       record.DataYear,
+			StartMonth:     startMonth,
+			EndMonth:       endMonth,
+			InvoicePrefix:  record.InvoicePrefix,
+		}
+		b.tracks[key] = track
+	}
+	track.add(span)
+}
+
+// report 檢查各字軌並回傳結果（依申報營業人、年度、期別、字軌排序）
+func (b *sequenceBuilder) report() *SequenceReport {
+	report := &SequenceReport{Tracks: make([]*InvoiceTrack, 0, len(b.tracks))}
+	for _, track := range b.tracks {
+		report.Tracks = append(report.Tracks, track)
+	}
+	sort.Slice(report.Tracks, func(a, b int) bool {
+		ta, tb := report.Tracks[a], report.Tracks[b]
+		if ta.DeclarantTaxId != tb.DeclarantTaxId {
+			return ta.DeclarantTaxId < tb.DeclarantTaxId
+		}
+		if ta.DataYear != tb.DataYear {
+			return ta.DataYear < tb.DataYear
+		}
+		if ta.StartMonth != tb.StartMonth {
+			return ta.StartMonth < tb.StartMonth
+		}
+		return ta.InvoicePrefix < tb.InvoicePrefix
+	})
+
+	// 先依卷找出非本期的號碼（同一字軌的各期別依期別排序），其餘號碼再檢查缺號、重複與未用完
+	periods := make(map[string][]*InvoiceTrack)
+	for _, track := range report.Tracks {
+		key := track.DeclarantTaxId + "_" + track.DataYear + "_" + track.InvoicePrefix
+		periods[key] = append(periods[key], track)
+	}
+	for _, group := range periods {
+		findOutOfPeriod(group)
+	}
+	for _, track := range report.Tracks {
+		track.analyze()
+		track.spans = nil
+	}
+	return report
+}
+
+// add 加入一筆資料的號碼範圍並累計各狀態張數
+func (t *InvoiceTrack) add(span invoiceSpan) {
+	switch span.status {
+	case InvoiceIssued:
+		t.Issued += span.end - span.start + 1
+	case InvoiceVoided:
+		t.Voided += span.end - span.start + 1
+	case InvoiceBlank:
+		t.Blank += span.end - span.start + 1
+	}
+	if len(t.spans) == 0 || span.start < t.StartNumber {
+		t.StartNumber = span.start
+	}
+	if len(t.spans) == 0 || span.end > t.EndNumber {
+		t.EndNumber = span.end
+	}
+	t.spans = append(t.spans, span)
+}
+
+// findOutOfPeriod 同一字軌在多個期別申報時（tracks 依期別排序），逐筆比對號碼所在的卷屬於哪一期：
+// 每卷歸申報張數最多的期別（張數相同時歸較早的期別），落在其他期別的卷的號碼列為非本期，
+// 並從本期的號碼範圍移除，不再列為缺號、重複或未用完
+func findOutOfPeriod(tracks []*InvoiceTrack) {
+	if len(tracks) < 2 {
+		return
+	}
+
+	// 各卷在各期別申報的張數
+	counts := make(map[int][]int)
+	for i, track := range tracks {
+		for _, span := range track.spans {
+			for booklet := span.start / invoiceBookletSize; booklet <= span.end/invoiceBookletSize; booklet++ {
+				if counts[booklet] == nil {
+					counts[booklet] = make([]int, len(tracks))
+				}
+				counts[booklet][i] += bookletPart(span, booklet).count()
+			}
+		}
+	}
+	owner := func(booklet int) int {
+		best := 0
+		for i, count := range counts[booklet] {
+			if count > counts[booklet][best] {
+				best = i
+			}
+		}
+		return best
+	}
+
+	for i, track := range tracks {
+		kept := make([]invoiceSpan, 0, len(track.spans))
+		for _, span := range track.spans {
+			// 依卷切開：屬於本期的部分保留，屬於其他期別的部分依所屬期別合併後列為非本期
+			var foreign invoiceSpan
+			foreignOwner := -1
+			flush := func() {
+				if foreignOwner < 0 {
+					return
+				}
+				other := tracks[foreignOwner]
+				track.addFinding(SequenceOutOfPeriod, foreign.start, foreign.end,
+					fmt.Sprintf("%s（%s）所在的卷主要由 %s 申報", span.status, span.location(), other.Period()))
+				foreignOwner = -1
+			}
+			for booklet := span.start / invoiceBookletSize; booklet <= span.end/invoiceBookletSize; booklet++ {
+				part := bookletPart(span, booklet)
+				switch ownerIndex := owner(booklet); {
+				case ownerIndex == i:
+					flush()
+					if last := len(kept) - 1; last >= 0 && kept[last].lineNumber == span.lineNumber && kept[last].fileName == span.fileName && kept[last].end+1 == part.start {
+						kept[last].end = part.end
+					} else {
+						kept = append(kept, part)
+					}
+				case ownerIndex == foreignOwner:
+					foreign.end = part.end
+				default:
+					flush()
+					foreign, foreignOwner = part, ownerIndex
+				}
+			}
+			flush()
+		}
+		track.spans = kept
+	}
+}
+
+// bookletPart 號碼範圍落在指定卷內的部分
+func bookletPart(span invoiceSpan, booklet int) invoiceSpan {
+	part := span
+	if head := booklet * invoiceBookletSize; part.start < head {
+		part.start = head
+	}
+	if tail := (booklet+1)*invoiceBookletSize - 1; part.end > tail {
+		part.end = tail
+	}
+	return part
+}
+
+// count 號碼張數
+func (s invoiceSpan) count() int {
+	return s.end - s.start + 1
+}
+
+// analyze 找出本期號碼（非本期的號碼已由 findOutOfPeriod 移除）的缺號、重複與未用完，並以本期號碼更新最小、最大號碼
+// 所有號碼皆屬於其他期別時，最小、最大號碼維持申報的範圍
+func (t *InvoiceTrack) analyze() {
+	if len(t.spans) == 0 {
+		t.sortFindings()
+		return
+	}
+	sort.SliceStable(t.spans, func(a, b int) bool {
+		return t.spans[a].start < t.spans[b].start
+	})
+
+	t.StartNumber = t.spans[0].start
+	var covering invoiceSpan // 目前涵蓋到最大號碼的資料
+	for i, span := range t.spans {
+		if i > 0 {
+			if span.start > covering.end+1 {
+				t.addFinding(SequenceGap, covering.end+1, span.start-1,
+					fmt.Sprintf("前一號碼見 %s，後一號碼見 %s", covering.location(), span.location()))
+			} else if span.start <= covering.end {
+				end := span.end
+				if covering.end < end {
+					end = covering.end
+				}
+				t.addFinding(SequenceOverlap, span.start, end,
+					fmt.Sprintf("%s（%s）與%s（%s）", covering.status, covering.location(), span.status, span.location()))
+			}
+		}
+		if i == 0 || span.end > covering.end {
+			covering = span
+		}
+	}
+	t.EndNumber = covering.end
+
+	// 同卷頭尾未申報的號碼
+	if head := t.StartNumber - t.StartNumber%invoiceBookletSize; head < t.StartNumber {
+		t.addFinding(SequenceUnusedBooklet, head, t.StartNumber-1,
+			fmt.Sprintf("本卷起號 %08d 至第一個申報號碼之間未申報", head))
+	}
+	if tail := t.EndNumber - t.EndNumber%invoiceBookletSize + invoiceBookletSize - 1; tail > t.EndNumber {
+		t.addFinding(SequenceUnusedBooklet, t.EndNumber+1, tail,
+			fmt.Sprintf("最後一個申報號碼至本卷訖號 %08d 之間未申報為空白未使用", tail))
+	}
+
+	t.sortFindings()
+}
+
+// sortFindings 檢查結果依起號排序
+func (t *InvoiceTrack) sortFindings() {
+	sort.SliceStable(t.Findings, func(a, b int) bool {
+		return t.Findings[a].StartNumber < t.Findings[b].StartNumber
+	})
+}
+
+// addFinding 加入檢查結果
+func (t *InvoiceTrack) addFinding(kind SequenceFindingKind, start, end int, description string) {
+	t.Findings = append(t.Findings, SequenceFinding{
+		Kind:        kind,
+		StartNumber: start,
+		EndNumber:   end,
+		Description: description,
+	})
+}
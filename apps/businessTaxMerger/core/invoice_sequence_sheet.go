@@ -0,0 +1,80 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// sequenceSheetName 字軌檢查工作表名稱
+const sequenceSheetName = "字軌檢查"
+
+// ExportInvoiceSequence 將字軌號碼檢查結果匯出為 Excel，回傳產出的檔案路徑
+func ExportInvoiceSequence(report *SequenceReport, outputFolder string) (string, error) {
+	if len(report.Tracks) == 0 {
+		return "", fmt.Errorf("沒有可檢查字軌的銷項發票資料")
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	xlsxPath := filepath.Join(outputFolder, fmt.Sprintf("發票字軌檢查_%s.xlsx", timestamp))
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	if err := writeSequenceSheet(f, report); err != nil {
+		return "", fmt.Errorf("產生字軌檢查 Excel 失敗: %v", err)
+	}
+	if err := f.DeleteSheet("Sheet1"); err != nil {
+		// 忽略錯誤，可能 Sheet1 不存在
+	}
+	f.SetActiveSheet(0)
+	if err := f.SaveAs(xlsxPath); err != nil {
+		os.Remove(xlsxPath)
+		return "", fmt.Errorf("產生字軌檢查 Excel 失敗: %v", err)
+	}
+	return xlsxPath, nil
+}
+
+// writeSequenceSheet 寫入字軌檢查工作表
+// 每個字軌先列一行範圍（最小至最大號碼與開立、作廢、空白張數），其下依號碼列出缺號、重複、非本期與未用完
+func writeSequenceSheet(f *excelize.File, report *SequenceReport) error {
+	w, err := newSheetWriter(f, sequenceSheetName)
+	if err != nil {
+		return err
+	}
+	if err := w.writeRow(w.header,
+		FieldLabel("DeclarantTaxId"), "期別", FieldLabel("InvoicePrefix"), "類別", "起號", "訖號", "張數", "說明",
+	); err != nil {
+		return err
+	}
+
+	for _, track := range report.Tracks {
+		summary := fmt.Sprintf("開立 %d 張、作廢 %d 張、空白未使用 %d 張", track.Issued, track.Voided, track.Blank)
+		if len(track.Findings) == 0 {
+			summary += "，號碼連續"
+		}
+		// 範圍列以合計樣式（粗體 + 上框線）區隔各字軌
+		if err := w.writeRow(w.total,
+			track.DeclarantTaxId, track.Period(), track.InvoicePrefix, "範圍",
+			fmt.Sprintf("%08d", track.StartNumber), fmt.Sprintf("%08d", track.EndNumber),
+			track.EndNumber-track.StartNumber+1, summary,
+		); err != nil {
+			return err
+		}
+		for _, finding := range track.Findings {
+			if err := w.writeRow(w.finding,
+				track.DeclarantTaxId, track.Period(), track.InvoicePrefix, finding.Kind.String(),
+				fmt.Sprintf("%08d", finding.StartNumber), fmt.Sprintf("%08d", finding.EndNumber),
+				finding.Count(), finding.Description,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 設定欄寬
+	return w.setColWidths(18, 18, 8, 10, 12, 12, 10, 70)
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// sequenceInvoice 銷項三聯式發票（字軌 AB）：taxType 1 為開立、F 為作廢
+func sequenceInvoice(month string, number int, taxType string) TaxRecord {
+	record := salesRecord("31", taxType, "1000", "50")
+	if taxType == "F" {
+		record.SalesAmount, record.TaxAmount = "0", "0"
+	}
+	record.DataMonth = month
+	record.InvoiceStartNumber = fmt.Sprintf("%08d", number)
+	return record
+}
+
+// sequenceBlank 空白未使用發票（字軌 AB），起號至訖號
+func sequenceBlank(month string, start, end int) TaxRecord {
+	record := sequenceInvoice(month, start, "D")
+	record.SalesAmount, record.TaxAmount = "0", "0"
+	record.BuyerTaxId = ""
+	record.BusinessNumber = fmt.Sprintf("%08d", end)
+	return record
+}
+
+// sequenceFinding 檢查結果的類別與號碼範圍（不比對說明）
+type sequenceFinding struct {
+	kind       SequenceFindingKind
+	start, end int
+}
+
+func TestAnalyzeInvoiceSequence(t *testing.T) {
+	cases := []struct {
+		name    string
+		records []TaxRecord
+		// want 各期別（起始月份）的檢查結果
+		want map[string][]sequenceFinding
+	}{
+		{
+			name: "號碼連續",
+			records: []TaxRecord{
+				sequenceInvoice("01", 50, "1"), sequenceInvoice("02", 51, "1"), sequenceInvoice("01", 52, "F"), sequenceBlank("02", 53, 99),
+			},
+			want: map[string][]sequenceFinding{"01": nil},
+		},
+		{
+			name: "缺號",
+			records: []TaxRecord{
+				sequenceInvoice("01", 50, "1"), sequenceInvoice("01", 51, "1"), sequenceInvoice("01", 54, "1"), sequenceBlank("01", 55, 99),
+			},
+			want: map[string][]sequenceFinding{"01": {{SequenceGap, 52, 53}}},
+		},
+		{
+			name: "開立又作廢",
+			records: []TaxRecord{
+				sequenceInvoice("01", 50, "1"), sequenceInvoice("01", 50, "F"), sequenceBlank("01", 51, 99),
+			},
+			want: map[string][]sequenceFinding{"01": {{SequenceOverlap, 50, 50}}},
+		},
+		{
+			name: "空白範圍與開立重疊",
+			records: []TaxRecord{
+				sequenceInvoice("01", 50, "1"), sequenceInvoice("01", 51, "1"), sequenceBlank("01", 51, 99),
+			},
+			want: map[string][]sequenceFinding{"01": {{SequenceOverlap, 51, 51}}},
+		},
+		{
+			name: "同卷頭尾未申報",
+			records: []TaxRecord{
+				sequenceInvoice("01", 53, "1"), sequenceInvoice("01", 54, "1"),
+			},
+			want: map[string][]sequenceFinding{"01": {{SequenceUnusedBooklet, 50, 52}, {SequenceUnusedBooklet, 55, 99}}},
+		},
+		{
+			name: "期別範圍不重疊時仍逐筆比對所在的卷",
+			// 01-02 月誤申報 03-04 月的號碼 250：舊的比對方式看兩期範圍（100-250、251-299）不重疊，不會列為非本期
+			records: []TaxRecord{
+				sequenceInvoice("01", 100, "1"), sequenceInvoice("01", 101, "1"), sequenceBlank("01", 102, 149), sequenceInvoice("02", 250, "1"),
+				sequenceInvoice("03", 251, "1"), sequenceBlank("04", 252, 299),
+			},
+			want: map[string][]sequenceFinding{
+				"01": {{SequenceOutOfPeriod, 250, 250}},
+				"03": {{SequenceUnusedBooklet, 250, 250}},
+			},
+		},
+		{
+			name: "空白範圍跨到其他期別的卷",
+			records: []TaxRecord{
+				sequenceInvoice("01", 0, "1"), sequenceBlank("01", 1, 59),
+				sequenceInvoice("03", 50, "1"), sequenceInvoice("03", 51, "1"), sequenceBlank("03", 60, 99), sequenceBlank("03", 52, 59),
+			},
+			want: map[string][]sequenceFinding{
+				"01": {{SequenceOutOfPeriod, 50, 59}},
+				"03": nil,
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txtFile := writeTestTxt(t, t.TempDir(), "sequence.txt", tc.records...)
+			ctx := context.Background()
+			fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ReleaseFiles(fileInfoList)
+
+			report, err := AnalyzeInvoiceSequence(ctx, [][]*TxtFileInfo{fileInfoList})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string][]sequenceFinding)
+			for _, track := range report.Tracks {
+				var findings []sequenceFinding
+				for _, finding := range track.Findings {
+					findings = append(findings, sequenceFinding{finding.Kind, finding.StartNumber, finding.EndNumber})
+				}
+				got[track.StartMonth] = findings
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("檢查結果 = %v，應為 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestInvoiceTrackCountsOutOfPeriodNumbers(t *testing.T) {
+	txtFile := writeTestTxt(t, t.TempDir(), "sequence.txt",
+		sequenceInvoice("01", 100, "1"), sequenceInvoice("01", 101, "F"), sequenceBlank("01", 102, 149), sequenceInvoice("01", 250, "1"),
+		sequenceInvoice("03", 251, "1"), sequenceBlank("03", 252, 299),
+	)
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	report, err := AnalyzeInvoiceSequence(ctx, [][]*TxtFileInfo{fileInfoList})
+	if err != nil {
+		t.Fatal(err)
+	}
+	track := report.Tracks[0]
+	// 非本期的號碼仍計入申報張數，但不計入本期的號碼範圍
+	if track.Issued != 2 || track.Voided != 1 || track.Blank != 48 {
+		t.Errorf("開立 %d、作廢 %d、空白 %d 張，應為 2、1、48 張", track.Issued, track.Voided, track.Blank)
+	}
+	if track.StartNumber != 100 || track.EndNumber != 149 {
+		t.Errorf("號碼範圍 %d-%d，應為 100-149", track.StartNumber, track.EndNumber)
+	}
+}
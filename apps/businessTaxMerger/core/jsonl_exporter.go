@@ -0,0 +1,74 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+)
+
+// JSONLinesExporter 每個分配各產出一個 JSON Lines 檔（每行一筆資料），可附加來源檔案與行號
+// 物件的鍵為欄位名稱（例如 FormatCode），順序依欄位設定；數字欄位輸出為 JSON 數值
+type JSONLinesExporter struct {
+	Profile        *ColumnProfile    // 欄位設定，nil 時使用預設欄位設定
+	Workers        int               // 並行產出的檔案數，<= 0 表示使用 DefaultWorkers
+	Validation     ValidationOptions // 驗證選項
+	OmitProvenance bool              // 不附加來源檔案與行號欄位，完全依欄位設定（預設在欄位設定後附加）
+}
+
+// Name 輸出格式名稱
+func (e *JSONLinesExporter) Name() string { return "JSON Lines" }
+
+// Export 匯出分配結果
+func (e *JSONLinesExporter) Export(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string) (*ValidationReport, error) {
+	profile := exportProfile(e.Profile, !e.OmitProvenance)
+	return exportRecordFiles(ctx, allocation, outputFolder, ".jsonl", e.Workers, e.Validation, func(w io.Writer) recordEncoder {
+		return &jsonLinesEncoder{out: w, profile: profile}
+	})
+}
+
+// jsonLinesEncoder 以 JSON Lines 逐筆寫出資料
+type jsonLinesEncoder struct {
+	out     io.Writer
+	profile *ColumnProfile
+	line    []byte
+}
+
+// writeHeader JSON Lines 沒有標題
+func (e *jsonLinesEncoder) writeHeader() error {
+	return nil
+}
+
+// writeRecord 寫入一筆資料為一行 JSON 物件
+func (e *jsonLinesEncoder) writeRecord(record *TaxRecord) ([]ValidationIssue, error) {
+	e.line = append(e.line[:0], '{')
+	for i, column := range e.profile.Columns {
+		if i > 0 {
+			e.line = append(e.line, ',')
+		}
+		key, err := json.Marshal(column.Field)
+		if err != nil {
+			return nil, err
+		}
+		e.line = append(e.line, key...)
+		e.line = append(e.line, ':')
+
+		var value interface{} = exportFields[column.Field].value(record)
+		if column.columnType() == ColumnNumber {
+			value = parseAmountToInt(value.(string))
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		e.line = append(e.line, encoded...)
+	}
+	e.line = append(e.line, '}', '\n')
+
+	_, err := e.out.Write(e.line)
+	return nil, err
+}
+
+// close JSON Lines 沒有緩衝的內容
+func (e *jsonLinesEncoder) close() error {
+	return nil
+}
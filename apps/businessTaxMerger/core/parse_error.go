@@ -0,0 +1,138 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+)
+
+// RecordLength 媒體檔每筆資料長度（位元組）
+const RecordLength = 81
+
+// ParseError 資料行結構錯誤
+type ParseError struct {
+	// FileName 來源檔案名稱
+	FileName string
+
+	// LineNumber 行號
+	LineNumber int
+
+	// Field 欄位名稱（TaxRecord 欄位名，整行錯誤為空字串）
+	Field string
+
+	// Reason 錯誤說明
+	Reason string
+}
+
+// Error 錯誤描述
+func (e ParseError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("%s 第 %d 行: %s", e.FileName, e.LineNumber, e.Reason)
+	}
+	return fmt.Sprintf("%s 第 %d 行 %s: %s", e.FileName, e.LineNumber, FieldLabel(e.Field), e.Reason)
+}
+
+// ParseErrors 單行資料的所有結構錯誤
+type ParseErrors []ParseError
+
+// Error 錯誤描述
+func (e ParseErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// 允許的代碼
+var (
+	// allowedTaxTypes 課稅別：1 應稅、2 零稅率、3 免稅、F 作廢、D 空白未使用
+	allowedTaxTypes = map[string]bool{"1": true, "2": true, "3": true, "F": true, "D": true}
+
+	// allowedDeductionCodes 扣抵代號（進項）：1 可扣抵進貨及費用、2 可扣抵固定資產、3 不可扣抵進貨及費用、4 不可扣抵固定資產
+	allowedDeductionCodes = map[string]bool{"1": true, "2": true, "3": true, "4": true}
+
+	// allowedAggregationMarks 彙加註記（銷項）：空白或 A
+	allowedAggregationMarks = map[string]bool{"": true, "A": true}
+)
+
+// numericField 數字欄位 9(n) 的位置
+type numericField struct {
+	field  string
+	start  int
+	length int
+}
+
+// checkStructure 檢查資料行結構：長度、格式代號、數字欄位與代碼欄位
+// data: 規格位元組（Big5）
+func checkStructure(record *TaxRecord, data []byte) ParseErrors {
+	errs := make(ParseErrors, 0)
+
+	addError := func(field, reason string) {
+		errs = append(errs, ParseError{
+			FileName:   record.SourceFileName,
+			LineNumber: record.LineNumber,
+			Field:      field,
+			Reason:     reason,
+		})
+	}
+
+	if len(data) != RecordLength {
+		addError("", fmt.Sprintf("資料長度應為 %d 位元組，實際為 %d 位元組", RecordLength, len(data)))
+		// 長度錯誤時欄位位置已不可信，不再逐欄檢查
+		return errs
+	}
+
+	if record.Kind == KindUnknown {
+		addError("FormatCode", fmt.Sprintf("未知的格式代號 '%s'", record.FormatCode))
+		return errs
+	}
+
+	// 數字欄位 9(n)
+	numericFields := []numericField{
+		{"DataYear", 18, 3},
+		{"DataMonth", 21, 2},
+		{"TaxAmount", 62, 10},
+	}
+	if record.Kind == KindCustoms {
+		numericFields = append(numericFields, numericField{"TaxBase", 49, 12})
+	} else {
+		numericFields = append(numericFields, numericField{"SalesAmount", 49, 12})
+	}
+	switch record.Kind {
+	case KindInvoice:
+		numericFields = append(numericFields, numericField{"InvoiceStartNumber", 41, 8})
+		if record.IsOutput() && record.IsBlank() {
+			numericFields = append(numericFields, numericField{"BusinessNumber", 23, 8})
+		}
+	case KindSummary:
+		numericFields = append(numericFields,
+			numericField{"BusinessNumber", 23, 8},
+			numericField{"TotalSheets", 31, 4},
+		)
+		if record.InvoicePrefix != "" {
+			numericFields = append(numericFields, numericField{"InvoiceStartNumber", 41, 8})
+		}
+	}
+	for _, nf := range numericFields {
+		if !isDigits(string(data[nf.start : nf.start+nf.length])) {
+			addError(nf.field, fmt.Sprintf("應為 %d 位數字", nf.length))
+		}
+	}
+
+	if isDigits(record.DataMonth) && (record.DataMonth < "01" || record.DataMonth > "12") {
+		addError("DataMonth", "月份應介於 01-12")
+	}
+
+	// 代碼欄位
+	if !allowedTaxTypes[record.TaxType] {
+		addError("TaxType", fmt.Sprintf("不允許的課稅別 '%s'", record.TaxType))
+	}
+	if record.IsInput() && !allowedDeductionCodes[record.DeductionCode] {
+		addError("DeductionCode", fmt.Sprintf("不允許的扣抵代號 '%s'", record.DeductionCode))
+	}
+	if record.IsOutput() && !allowedAggregationMarks[record.AggregationMark] {
+		addError("AggregationMark", fmt.Sprintf("不允許的彙加註記 '%s'", record.AggregationMark))
+	}
+
+	return errs
+}
@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// replaceAt 將資料行位置 start（0 起算，位元組）起的內容換成 value（資料行須為 ASCII）
+func replaceAt(line string, start int, value string) string {
+	return line[:start] + value + line[start+len(value):]
+}
+
+func TestCheckStructure(t *testing.T) {
+	sales := testLine(t, salesRecord("31", "1", "1000", "50"))
+	purchase := testLine(t, purchaseRecord("21", "1", "", "1000", "50"))
+	blank := testLine(t, sequenceBlank("01", 50, 99))
+	summary := testLine(t, summaryRecord(100, 149, 50))
+	customs := testLine(t, TaxRecord{
+		FormatCode:              "28",
+		DeclarantTaxId:          "123456789",
+		DataYear:                "113",
+		DataMonth:               "01",
+		BuyerTaxId:              "04595257",
+		CustomsTaxPaymentNumber: "AA123456789012",
+		TaxBase:                 "200000",
+		TaxType:                 "1",
+		TaxAmount:               "10000",
+		DeductionCode:           "1",
+	})
+
+	cases := []struct {
+		name string
+		line string
+		// want 有錯誤的欄位（整行錯誤為空字串，nil 表示沒有錯誤）
+		want []string
+	}{
+		{name: "銷項發票", line: sales},
+		{name: "進項發票", line: purchase},
+		{name: "空白未使用發票", line: blank},
+		{name: "彙總登錄", line: summary},
+		{name: "海關代徵營業稅繳納證", line: customs},
+		{name: "長度不足", line: sales[:RecordLength-1], want: []string{""}},
+		{name: "長度過長", line: sales + " ", want: []string{""}},
+		{name: "長度錯誤時不再逐欄檢查", line: replaceAt(sales, 61, "9")[:RecordLength-1], want: []string{""}},
+		{name: "未知的格式代號", line: replaceAt(sales, 0, "99"), want: []string{"FormatCode"}},
+		{name: "未知的格式代號時不再逐欄檢查", line: replaceAt(replaceAt(sales, 0, "99"), 61, "9"), want: []string{"FormatCode"}},
+		{name: "年度非數字", line: replaceAt(sales, 18, "11A"), want: []string{"DataYear"}},
+		{name: "月份非數字", line: replaceAt(sales, 21, " 1"), want: []string{"DataMonth"}},
+		{name: "月份 00", line: replaceAt(sales, 21, "00"), want: []string{"DataMonth"}},
+		{name: "月份 13", line: replaceAt(sales, 21, "13"), want: []string{"DataMonth"}},
+		{name: "月份 12", line: replaceAt(sales, 21, "12")},
+		{name: "銷售金額非數字", line: replaceAt(sales, 49, "00000000100A"), want: []string{"SalesAmount"}},
+		{name: "營業稅額空白", line: replaceAt(sales, 62, "          "), want: []string{"TaxAmount"}},
+		{name: "發票號碼非數字", line: replaceAt(sales, 41, "0000000A"), want: []string{"InvoiceStartNumber"}},
+		{name: "空白未使用發票訖號非數字", line: replaceAt(blank, 23, "000000 9"), want: []string{"BusinessNumber"}},
+		{name: "彙總登錄訖號非數字", line: replaceAt(summary, 23, "0000014X"), want: []string{"BusinessNumber"}},
+		{name: "彙總登錄張數非數字", line: replaceAt(summary, 31, "00 5"), want: []string{"TotalSheets"}},
+		{name: "海關稅基非數字", line: replaceAt(customs, 49, "0000002000-0"), want: []string{"TaxBase"}},
+		{name: "課稅別不允許", line: replaceAt(sales, 61, "9"), want: []string{"TaxType"}},
+		{name: "課稅別空白", line: replaceAt(sales, 61, " "), want: []string{"TaxType"}},
+		{name: "進項扣抵代號不允許", line: replaceAt(purchase, 72, "5"), want: []string{"DeductionCode"}},
+		{name: "進項扣抵代號空白", line: replaceAt(purchase, 72, " "), want: []string{"DeductionCode"}},
+		{name: "銷項不檢查扣抵代號", line: replaceAt(sales, 72, "5")},
+		{name: "彙加註記不允許", line: replaceAt(sales, 79, "B"), want: []string{"AggregationMark"}},
+		{name: "彙加註記 A", line: replaceAt(sales, 79, "A")},
+		{name: "進項不檢查彙加註記", line: replaceAt(purchase, 79, "B")},
+		{name: "同一行多個錯誤依欄位順序列出", line: replaceAt(replaceAt(purchase, 18, "ABC"), 72, "9"), want: []string{"DataYear", "DeductionCode"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseLine(tc.line, 7, "structure.txt")
+			var got []string
+			if err != nil {
+				var errs ParseErrors
+				if !errors.As(err, &errs) {
+					t.Fatalf("錯誤類型 = %T，應為 ParseErrors", err)
+				}
+				for _, e := range errs {
+					if e.FileName != "structure.txt" || e.LineNumber != 7 {
+						t.Errorf("錯誤位置 = %s 第 %d 行，應為 structure.txt 第 7 行", e.FileName, e.LineNumber)
+					}
+					got = append(got, e.Field)
+				}
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("有錯誤的欄位 = %q，應為 %q（%v）", got, tc.want, err)
+			}
+		})
+	}
+}
+
+func TestCheckStructureCountsBig5Bytes(t *testing.T) {
+	// 備註欄多一個全形字：字元數不變時 Big5 長度多 1 位元組
+	line := testLine(t, big5RemarkRecord())
+	line = strings.Replace(line, "備註 ", "備註註", 1)
+	_, err := ParseLine(line, 1, "big5.txt")
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || !strings.Contains(errs[0].Reason, "實際為 82 位元組") {
+		t.Errorf("錯誤 = %v，應為資料長度 82 位元組", err)
+	}
+}
@@ -0,0 +1,31 @@
+package core
+
+import (
+	"context"
+)
+
+// PeriodReport 須以整期資料計算的結果（同一期別可能分配到多個 Excel）：403 試算、401 申報書與銷項發票字軌檢查
+// AnalyzePeriods 讀取分配結果一次同時計算，匯出 Excel 與之後的 401 試算、字軌檢查共用
+type PeriodReport struct {
+	// Sequence 銷項發票字軌檢查
+	Sequence *SequenceReport
+
+	// returns403 各期 403 試算（含 401 的計算）
+	returns403 *return403Totals
+}
+
+// AnalyzePeriods 讀取分配結果中的所有資料一次，依申報營業人與申報期別計算 403 試算、401 申報書與字軌檢查
+func AnalyzePeriods(ctx context.Context, allocation [][]*TxtFileInfo) (*PeriodReport, error) {
+	returns := newReturn403Totals()
+	sequence := newSequenceBuilder()
+	if err := scanFiles(ctx, allocationFiles(allocation), returns.add, sequence.add); err != nil {
+		return nil, err
+	}
+	returns.calculate()
+	return &PeriodReport{Sequence: sequence.report(), returns403: returns}, nil
+}
+
+// Returns401 各期 401 申報書（同 ComputeReturn401），每次呼叫回傳新的複本
+func (p *PeriodReport) Returns401() []*Return401 {
+	return p.returns403.returns401()
+}
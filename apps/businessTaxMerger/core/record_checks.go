@@ -0,0 +1,41 @@
+package core
+
+import (
+	"context"
+)
+
+// RecordChecks 分配前的跨資料檢查結果：重複發票與彙總登錄範圍
+type RecordChecks struct {
+	// Duplicates 重複發票
+	Duplicates *DuplicateReport
+
+	// ranges 彙總登錄範圍的比對結果，由 SummaryRangeIssues 轉為驗證問題
+	ranges *summaryRangeChecker
+}
+
+// CheckFiles 讀取各檔案一次，同時比對跨檔重複發票與彙總登錄範圍（同 FindDuplicates、CheckSummaryRanges）
+// 須在分配（拆分片段）之前呼叫；要刪除完全重複的資料時，先以 DropExactDuplicates 刪除再取得 SummaryRangeIssues
+func CheckFiles(ctx context.Context, fileInfoList []*TxtFileInfo) (*RecordChecks, error) {
+	return checkFiles(ctx, fileInfoList, nil)
+}
+
+// checkFiles 同 CheckFiles，handle 不為 nil 時每筆資料也交給 handle（例如逐筆驗證），共用同一次讀取
+func checkFiles(ctx context.Context, fileInfoList []*TxtFileInfo, handle func(fileInfo *TxtFileInfo, record *TaxRecord)) (*RecordChecks, error) {
+	finder := newDuplicateFinder(fileInfoList)
+	checker := newSummaryRangeChecker(fileInfoList)
+	handles := []func(fileInfo *TxtFileInfo, record *TaxRecord){finder.add, checker.add}
+	if handle != nil {
+		handles = append(handles, handle)
+	}
+	if err := scanFiles(ctx, fileInfoList, handles...); err != nil {
+		return nil, err
+	}
+	checker.finish()
+	return &RecordChecks{Duplicates: finder.finish(), ranges: checker}, nil
+}
+
+// SummaryRangeIssues 彙總登錄範圍的驗證問題，並在各檔案記錄有問題的資料列（匯出時加入驗證報告）
+// 已由 DropExactDuplicates 刪除的資料不列入
+func (c *RecordChecks) SummaryRangeIssues() []ValidationIssue {
+	return c.ranges.issues(c.Duplicates.droppedLines())
+}
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// analyzeFile 讀取並解析檔案一次：計算行數、偵測編碼、收集結構錯誤，
+// 並將有效資料寫入本機暫存檔（spill file），後續分配與匯出直接讀取暫存檔，不再重讀原始檔
+// ctx 取消時停止讀取並刪除暫存檔
+func analyzeFile(ctx context.Context, filePath string) (*TxtFileInfo, error) {
+	file, err := openTxtFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	spill, err := os.CreateTemp("", "accountingTools_*.spill")
+	if err != nil {
+		return nil, fmt.Errorf("建立暫存檔失敗: %v", err)
+	}
+
+	info := NewTxtFileInfo(filePath, 0, file.Encoding)
+	info.SpillPath = spill.Name()
+	info.ParseErrors = make([]ParseError, 0)
+
+	writer := bufio.NewWriter(spill)
+
+	scanErr := func() error {
+		for file.Scan() {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			info.LineCount++
+			line := file.Text()
+
+			if len(strings.TrimSpace(line)) == 0 {
+				continue
+			}
+
+			record, err := ParseLine(line, info.LineCount, info.FileName)
+			if err != nil {
+				var errs ParseErrors
+				if !errors.As(err, &errs) {
+					return err
+				}
+				info.ParseErrors = append(info.ParseErrors, errs...)
+				continue
+			}
+
+			// 暫存格式：行號<TAB>原始資料（已轉為 UTF-8）
+			if _, err := fmt.Fprintf(writer, "%d\t%s\n", info.LineCount, line); err != nil {
+				return err
+			}
+			info.RecordCount++
+			info.analyzed.add(record)
+		}
+		if err := file.Err(); err != nil {
+			return err
+		}
+		return writer.Flush()
+	}()
+	// 讀到開頭取樣之後才出現 Big5 字元時，編碼在讀取過程中才確定
+	info.Encoding = file.Encoding
+
+	closeErr := spill.Close()
+	if scanErr == nil {
+		scanErr = closeErr
+	}
+	if scanErr != nil {
+		os.Remove(info.SpillPath)
+		return nil, scanErr
+	}
+
+	return info, nil
+}
+
+// EachRecord 依序讀取暫存檔中的有效資料，每筆交給 handle 處理（拆分片段只讀取片段範圍內的資料）
+// handle 回傳錯誤時立即停止
+func (info *TxtFileInfo) EachRecord(handle func(record *TaxRecord) error) error {
+	file, err := os.Open(info.SpillPath)
+	if err != nil {
+		return fmt.Errorf("讀取暫存檔失敗: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for position := 0; scanner.Scan(); position++ {
+		if info.IsPart() {
+			if position < info.RecordOffset {
+				continue
+			}
+			if position >= info.RecordOffset+info.RecordCount {
+				break
+			}
+		}
+
+		lineNumber, line, err := splitSpillLine(scanner.Text())
+		if err != nil {
+			return err
+		}
+
+		record, err := ParseLine(line, lineNumber, info.FileName)
+		if err != nil {
+			return err
+		}
+		if err := handle(record); err != nil {
+			return err
+		}
+	}
+
+	return scanner.Err()
+}
+
+// scanFiles 依序讀取各檔案的暫存檔一次，每筆資料依序交給所有 handles（多項檢查共用同一次讀取）
+// ctx 取消或讀取失敗時立即停止
+func scanFiles(ctx context.Context, fileInfoList []*TxtFileInfo, handles ...func(fileInfo *TxtFileInfo, record *TaxRecord)) error {
+	for _, fileInfo := range fileInfoList {
+		err := fileInfo.EachRecord(func(record *TaxRecord) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			for _, handle := range handles {
+				handle(fileInfo, record)
+			}
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("讀取檔案 %s 失敗: %v", fileInfo.FileName, err)
+		}
+	}
+	return nil
+}
+
+// allocationFiles 分配結果中的所有檔案（依分配順序）
+func allocationFiles(allocation [][]*TxtFileInfo) []*TxtFileInfo {
+	files := make([]*TxtFileInfo, 0)
+	for _, fileGroup := range allocation {
+		files = append(files, fileGroup...)
+	}
+	return files
+}
+
+// Release 刪除暫存檔（拆分片段與原始檔共用暫存檔，由原始檔負責刪除）
+func (info *TxtFileInfo) Release() {
+	if info.source != nil {
+		return
+	}
+	if info.SpillPath != "" {
+		os.Remove(info.SpillPath)
+		info.SpillPath = ""
+	}
+}
+
+// ReleaseFiles 刪除所有檔案的暫存檔（處理完成或放棄處理時呼叫）
+func ReleaseFiles(fileInfoList []*TxtFileInfo) {
+	for _, info := range fileInfoList {
+		info.Release()
+	}
+}
+
+// splitSpillLine 拆開暫存檔的一行：行號<TAB>原始資料
+func splitSpillLine(text string) (int, string, error) {
+	lineNumberText, line, found := strings.Cut(text, "\t")
+	if !found {
+		return 0, "", fmt.Errorf("暫存檔格式錯誤")
+	}
+	lineNumber, err := strconv.Atoi(lineNumberText)
+	if err != nil {
+		return 0, "", fmt.Errorf("暫存檔格式錯誤: %v", err)
+	}
+	return lineNumber, line, nil
+}
+
+// measureParts 讀取暫存檔一次，補上各片段的行號範圍與筆數金額（parts 須依位置排序且涵蓋所有資料）
+// 各片段合計與分析時記錄的不符時表示暫存檔已變動，回傳錯誤
+func (info *TxtFileInfo) measureParts(parts []*TxtFileInfo) error {
+	file, err := os.Open(info.SpillPath)
+	if err != nil {
+		return fmt.Errorf("讀取暫存檔失敗: %v", err)
+	}
+	defer file.Close()
+
+	var total recordTotal
+	index := 0
+	scanner := bufio.NewScanner(file)
+	for position := 0; scanner.Scan(); position++ {
+		for index < len(parts) && position >= parts[index].RecordOffset+parts[index].RecordCount {
+			index++
+		}
+		if index == len(parts) {
+			break
+		}
+		part := parts[index]
+
+		lineNumber, line, err := splitSpillLine(scanner.Text())
+		if err != nil {
+			return err
+		}
+		record, err := ParseLine(line, lineNumber, info.FileName)
+		if err != nil {
+			return err
+		}
+		if position == part.RecordOffset {
+			part.StartLine = lineNumber
+		}
+		part.EndLine = lineNumber
+		part.analyzed.add(record)
+		total.add(record)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if total != info.analyzed {
+		return fmt.Errorf("暫存資料的筆數或金額與分析時不符")
+	}
+	return nil
+}
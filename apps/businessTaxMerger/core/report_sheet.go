@@ -0,0 +1,197 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	// validationSheetName 驗證報告工作表名稱
+	validationSheetName = "驗證報告"
+
+	// parseErrorSheetName 錯誤清單工作表名稱
+	parseErrorSheetName = "錯誤清單"
+
+	// sourceSheetName 來源檔案工作表名稱
+	sourceSheetName = "來源檔案"
+)
+
+// writeValidationSheet 寫入驗證報告工作表
+func writeValidationSheet(f *excelize.File, issues []ValidationIssue) error {
+	if _, err := f.NewSheet(validationSheetName); err != nil {
+		return err
+	}
+
+	headerStyle, err := newHeaderStyle(f)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"來源檔案", "行號", "欄位", "內容", "說明"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.SetCellValue(validationSheetName, cell, header); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(validationSheetName, cell, cell, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	for i, issue := range issues {
+		row := i + 2
+		values := []interface{}{
+			issue.FileName,
+			issue.LineNumber,
+			FieldLabel(issue.Field),
+			issue.Value,
+			issue.Reason,
+		}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			if err := f.SetCellValue(validationSheetName, cell, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 設定欄寬
+	widths := []float64{30, 8, 20, 20, 40}
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(validationSheetName, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeParseErrorSheet 寫入錯誤清單工作表（結構錯誤而未匯入的資料行）
+func writeParseErrorSheet(f *excelize.File, parseErrors []ParseError) error {
+	if _, err := f.NewSheet(parseErrorSheetName); err != nil {
+		return err
+	}
+
+	headerStyle, err := newHeaderStyle(f)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"來源檔案", "行號", "欄位", "說明"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.SetCellValue(parseErrorSheetName, cell, header); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(parseErrorSheetName, cell, cell, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	for i, parseError := range parseErrors {
+		row := i + 2
+		field := ""
+		if parseError.Field != "" {
+			field = FieldLabel(parseError.Field)
+		}
+		values := []interface{}{
+			parseError.FileName,
+			parseError.LineNumber,
+			field,
+			parseError.Reason,
+		}
+		for j, value := range values {
+			cell, _ := excelize.CoordinatesToCellName(j+1, row)
+			if err := f.SetCellValue(parseErrorSheetName, cell, value); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 設定欄寬
+	widths := []float64{30, 8, 20, 50}
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(parseErrorSheetName, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeSourceSheet 寫入來源檔案工作表：各資料工作表包含哪些來源檔案的哪些行，以及在資料工作表中的列範圍
+// sheetNames 與 fileGroups 一一對應
+func writeSourceSheet(f *excelize.File, sheetNames []string, fileGroups [][]*TxtFileInfo) error {
+	if _, err := f.NewSheet(sourceSheetName); err != nil {
+		return err
+	}
+
+	headerStyle, err := newHeaderStyle(f)
+	if err != nil {
+		return err
+	}
+
+	headers := []string{"工作表", "來源檔案", "片段", "分組", "起始行號", "結束行號", "資料筆數", "資料起始列", "資料結束列"}
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		if err := f.SetCellValue(sourceSheetName, cell, header); err != nil {
+			return err
+		}
+		if err := f.SetCellStyle(sourceSheetName, cell, cell, headerStyle); err != nil {
+			return err
+		}
+	}
+
+	row := 2
+	for sheetIndex, fileGroup := range fileGroups {
+		dataRow := 2 // 資料工作表從第二列開始
+		for _, fileInfo := range fileGroup {
+			part := "完整檔案"
+			if fileInfo.GroupBy != GroupByNone {
+				part = "該組全部"
+			}
+			if fileInfo.IsPart() {
+				part = fmt.Sprintf("%d/%d", fileInfo.PartNumber, fileInfo.PartCount)
+			}
+			// 片段與分組資料記錄實際的行號範圍，完整檔案則為全部行數
+			startLine, endLine := fileInfo.StartLine, fileInfo.EndLine
+			if startLine == 0 {
+				startLine, endLine = 1, fileInfo.LineCount
+			}
+
+			values := []interface{}{
+				sheetNames[sheetIndex],
+				fileInfo.FileName,
+				part,
+				fileInfo.GroupLabel(),
+				startLine,
+				endLine,
+				fileInfo.RecordCount,
+				dataRow,
+				dataRow + fileInfo.RecordCount - 1,
+			}
+			for j, value := range values {
+				cell, _ := excelize.CoordinatesToCellName(j+1, row)
+				if err := f.SetCellValue(sourceSheetName, cell, value); err != nil {
+					return err
+				}
+			}
+			dataRow += fileInfo.RecordCount
+			row++
+		}
+	}
+
+	// 設定欄寬
+	widths := []float64{20, 30, 10, 24, 12, 12, 12, 12, 12}
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := f.SetColWidth(sourceSheetName, col, col, width); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,277 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// taxRate 一般稅額計算的營業稅率
+const taxRate = 0.05
+
+// Return401Amount 金額與稅額
+type Return401Amount struct {
+	Amount int64 `json:"amount"`
+	Tax    int64 `json:"tax"`
+}
+
+// add 加入金額與稅額
+func (a *Return401Amount) add(amount, tax int64) {
+	a.Amount += amount
+	a.Tax += tax
+}
+
+// Return401SalesLine 401 申報書銷項的一列（依格式代號）
+type Return401SalesLine struct {
+	FormatCode string          `json:"formatCode"`
+	Label      string          `json:"label"`
+	Taxable    Return401Amount `json:"taxable"`   // 應稅銷售額與稅額
+	ZeroRated  int64           `json:"zeroRated"` // 零稅率銷售額
+	Exempt     int64           `json:"exempt"`    // 免稅銷售額
+}
+
+// Return401 營業人銷售額與稅額申報書（401）主要欄位，依申報營業人與申報期別（雙月）計算
+type Return401 struct {
+	DeclarantTaxId string `json:"declarantTaxId"`
+	DataYear       string `json:"dataYear"`
+	StartMonth     string `json:"startMonth"`
+	EndMonth       string `json:"endMonth"`
+
+	// ===== 銷項 =====
+
+	// Sales 銷項各格式代號（31、32、35、36）
+	Sales []*Return401SalesLine `json:"sales"`
+	// SalesReturns 銷貨退回及折讓（33、34），於合計中扣除
+	SalesReturns []*Return401SalesLine `json:"salesReturns"`
+	// SalesTotal 銷項合計（已扣除退回及折讓）
+	SalesTotal Return401SalesLine `json:"salesTotal"`
+
+	// ===== 進項（可扣抵）=====
+
+	// Purchases 進貨及費用（扣抵代號 1），含海關代徵營業稅繳納證
+	Purchases Return401Amount `json:"purchases"`
+	// FixedAssets 固定資產（扣抵代號 2）
+	FixedAssets Return401Amount `json:"fixedAssets"`
+	// PurchaseReturns 進貨及費用之進貨退出及折讓（23、24、29，扣抵代號 1）
+	PurchaseReturns Return401Amount `json:"purchaseReturns"`
+	// FixedAssetReturns 固定資產之進貨退出及折讓（23、24、29，扣抵代號 2）
+	FixedAssetReturns Return401Amount `json:"fixedAssetReturns"`
+	// NonDeductible 不得扣抵的進項（扣抵代號 3、4，僅供參考）
+	NonDeductible Return401Amount `json:"nonDeductible"`
+
+	// ===== 稅額計算（括號內為申報書欄位代號）=====
+
+	OutputTax            int64 `json:"outputTax"`            // 本期銷項稅額合計 (101)
+	InputTax             int64 `json:"inputTax"`             // 得扣抵進項稅額合計 (107)
+	PreviousCredit       int64 `json:"previousCredit"`       // 上期累積留抵稅額 (108)
+	CreditSubtotal       int64 `json:"creditSubtotal"`       // 小計 (110)
+	PayableTax           int64 `json:"payableTax"`           // 本期應實繳稅額 (111)
+	CreditTax            int64 `json:"creditTax"`            // 本期申報留抵稅額 (112)
+	RefundLimit          int64 `json:"refundLimit"`          // 得退稅限額合計 (113)
+	RefundTax            int64 `json:"refundTax"`            // 本期應退稅額 (114)
+	CarriedForwardCredit int64 `json:"carriedForwardCredit"` // 本期累積留抵稅額 (115)
+
+	// ===== 未計入的資料 =====
+
+	// SpecialTaxRecords 特種稅額計算的資料筆數（37、38，屬 403 申報書）
+	SpecialTaxRecords int `json:"specialTaxRecords"`
+	// VoidedRecords 作廢或空白未使用的資料筆數（課稅別 F、D）
+	VoidedRecords int `json:"voidedRecords"`
+}
+
+// 401 申報書銷項列（依申報書順序）
+var return401SalesLabels = []struct {
+	FormatCode string
+	Label      string
+}{
+	{"31", "三聯式發票、電子計算機發票"},
+	{"35", "收銀機發票(三聯式)及電子發票"},
+	{"32", "二聯式發票、收銀機發票(二聯式)"},
+	{"36", "免用發票"},
+}
+
+// 401 申報書銷貨退回及折讓列
+var return401SalesReturnLabels = []struct {
+	FormatCode string
+	Label      string
+}{
+	{"33", "減：退回及折讓（三聯式）"},
+	{"34", "減：退回及折讓（二聯式）"},
+}
+
+// taxIncludedFormatCodes 銷售金額為含稅金額的格式代號（二聯式、免用發票），稅額欄為 0 時由含稅金額推算
+var taxIncludedFormatCodes = map[string]bool{
+	"32": true,
+	"34": true,
+	"36": true,
+}
+
+// Period 申報期別，例如「113 年 01-02 月」
+func (r *Return401) Period() string {
+	return fmt.Sprintf("%s 年 %s-%s 月", r.DataYear, r.StartMonth, r.EndMonth)
+}
+
+// SetPreviousCredit 設定上期累積留抵稅額並重新計算應納或留抵稅額
+func (r *Return401) SetPreviousCredit(previousCredit int64) {
+	r.PreviousCredit = previousCredit
+	r.calculate()
+}
+
+// calculate 由銷項與進項合計計算應納、留抵與應退稅額
+func (r *Return401) calculate() {
+	// 銷項合計（扣除退回及折讓）
+	r.SalesTotal = Return401SalesLine{Label: "合計"}
+	for _, line := range r.Sales {
+		r.SalesTotal.Taxable.add(line.Taxable.Amount, line.Taxable.Tax)
+		r.SalesTotal.ZeroRated += line.ZeroRated
+		r.SalesTotal.Exempt += line.Exempt
+	}
+	for _, line := range r.SalesReturns {
+		r.SalesTotal.Taxable.add(-line.Taxable.Amount, -line.Taxable.Tax)
+		r.SalesTotal.ZeroRated -= line.ZeroRated
+		r.SalesTotal.Exempt -= line.Exempt
+	}
+
+	r.OutputTax = r.SalesTotal.Taxable.Tax
+	purchaseTax := r.Purchases.Tax - r.PurchaseReturns.Tax
+	fixedAssetTax := r.FixedAssets.Tax - r.FixedAssetReturns.Tax
+	r.InputTax = purchaseTax + fixedAssetTax
+	r.CreditSubtotal = r.InputTax + r.PreviousCredit
+
+	r.PayableTax, r.CreditTax = 0, 0
+	if r.OutputTax > r.CreditSubtotal {
+		r.PayableTax = r.OutputTax - r.CreditSubtotal
+	} else {
+		r.CreditTax = r.CreditSubtotal - r.OutputTax
+	}
+
+	// 得退稅限額 = 零稅率銷售額 × 5% + 固定資產之進項稅額
+	r.RefundLimit = int64(math.Round(float64(r.SalesTotal.ZeroRated)*taxRate)) + fixedAssetTax
+	r.RefundTax = r.CreditTax
+	if r.RefundTax > r.RefundLimit {
+		r.RefundTax = r.RefundLimit
+	}
+	if r.RefundTax < 0 {
+		r.RefundTax = 0
+	}
+	r.CarriedForwardCredit = r.CreditTax - r.RefundTax
+}
+
+// salesLine 取得指定格式代號的銷項列（包含退回及折讓列）
+func (r *Return401) salesLine(formatCode string) *Return401SalesLine {
+	for _, line := range r.Sales {
+		if line.FormatCode == formatCode {
+			return line
+		}
+	}
+	for _, line := range r.SalesReturns {
+		if line.FormatCode == formatCode {
+			return line
+		}
+	}
+	return nil
+}
+
+// addRecord 將一筆資料計入申報書
+func (r *Return401) addRecord(record *TaxRecord) {
+	if record.IsVoided() || record.IsBlank() {
+		r.VoidedRecords++
+		return
+	}
+
+	amount := parseAmountToInt(record.Amount())
+	tax := parseAmountToInt(record.TaxAmount)
+
+	switch record.FormatCode {
+	case "31", "32", "33", "34", "35", "36":
+		line := r.salesLine(record.FormatCode)
+		switch record.TaxType {
+		case "1":
+			if taxIncludedFormatCodes[record.FormatCode] && tax == 0 {
+				amount, tax = splitTaxIncluded(amount)
+			}
+			line.Taxable.add(amount, tax)
+		case "2":
+			line.ZeroRated += amount
+		case "3":
+			line.Exempt += amount
+		}
+	case "37", "38":
+		r.SpecialTaxRecords++
+	case "21", "22", "25", "26", "27", "28":
+		switch record.DeductionCode {
+		case "1":
+			r.Purchases.add(amount, tax)
+		case "2":
+			r.FixedAssets.add(amount, tax)
+		default:
+			r.NonDeductible.add(amount, tax)
+		}
+	case "23", "24", "29":
+		switch record.DeductionCode {
+		case "1":
+			r.PurchaseReturns.add(amount, tax)
+		case "2":
+			r.FixedAssetReturns.add(amount, tax)
+		default:
+			r.NonDeductible.add(-amount, -tax)
+		}
+	}
+}
+
+// splitTaxIncluded 將含稅金額拆為銷售額與稅額（銷售額 = 含稅金額 ÷ 1.05 四捨五入）
+func splitTaxIncluded(taxIncluded int64) (int64, int64) {
+	amount := int64(math.Round(float64(taxIncluded) / (1 + taxRate)))
+	return amount, taxIncluded - amount
+}
+
+// newReturn401 建立空白申報書
+func newReturn401(declarantTaxId, dataYear, startMonth, endMonth string) *Return401 {
+	r := &Return401{
+		DeclarantTaxId: declarantTaxId,
+		DataYear:       dataYear,
+		StartMonth:     startMonth,
+		EndMonth:       endMonth,
+	}
+	for _, item := range return401SalesLabels {
+		r.Sales = append(r.Sales, &Return401SalesLine{FormatCode: item.FormatCode, Label: item.Label})
+	}
+	for _, item := range return401SalesReturnLabels {
+		r.SalesReturns = append(r.SalesReturns, &Return401SalesLine{FormatCode: item.FormatCode, Label: item.Label})
+	}
+	return r
+}
+
+// reportingPeriod 資料所屬月份對應的申報期別（雙月：01-02、03-04…）
+func reportingPeriod(dataMonth string) (string, string) {
+	month, err := strconv.Atoi(dataMonth)
+	if err != nil || month < 1 || month > 12 {
+		return dataMonth, dataMonth
+	}
+	start := month - (month-1)%2
+	return fmt.Sprintf("%02d", start), fmt.Sprintf("%02d", start+1)
+}
+
+// returnPeriodKey 資料所屬申報營業人與申報期別的鍵（401、403 申報書皆依此分開計算），依申報營業人、期別排序
+func returnPeriodKey(record *TaxRecord) string {
+	startMonth, _ := reportingPeriod(record.DataMonth)
+	return periodKey(record.DeclarantTaxId, record.DataYear, startMonth)
+}
+
+// periodKey 申報營業人與申報期別（年度 + 起始月份）的鍵
+func periodKey(declarantTaxId, year, startMonth string) string {
+	return declarantTaxId + "_" + year + startMonth
+}
+
+// ComputeReturn401 讀取分配結果中的所有資料，依申報營業人與申報期別計算 401 申報書
+// 回傳依申報營業人、期別排序；上期累積留抵稅額預設為 0，可再以 SetPreviousCredit 設定
+// 已由 AnalyzePeriods 計算過時改用 PeriodReport.Returns401，不必再讀取一次
+func ComputeReturn401(ctx context.Context, allocation [][]*TxtFileInfo) ([]*Return401, error) {
+	totals := newReturn403Totals()
+	if err := scanFiles(ctx, allocationFiles(allocation), totals.add); err != nil {
+		return nil, err
+	}
+	totals.calculate()
+	return totals.returns401(), nil
+}
@@ -0,0 +1,183 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ExportReturn401 將 401 申報書試算結果匯出為 Excel（每個申報營業人、期別一個工作表）與 JSON
+// 回傳產出的 Excel 與 JSON 檔案路徑
+func ExportReturn401(returns []*Return401, outputFolder string) (string, string, error) {
+	if len(returns) == 0 {
+		return "", "", fmt.Errorf("沒有可計算 401 申報書的資料")
+	}
+
+	timestamp := time.Now().Format("20060102_150405")
+	baseName := fmt.Sprintf("營業人銷售額與稅額申報書_%s", timestamp)
+	xlsxPath := filepath.Join(outputFolder, baseName+".xlsx")
+	jsonPath := filepath.Join(outputFolder, baseName+".json")
+
+	if err := writeReturn401Workbook(returns, xlsxPath); err != nil {
+		return "", "", fmt.Errorf("產生 401 申報書 Excel 失敗: %v", err)
+	}
+
+	data, err := json.MarshalIndent(returns, "", "  ")
+	if err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(jsonPath, data, 0644); err != nil {
+		return "", "", fmt.Errorf("產生 401 申報書 JSON 失敗: %v", err)
+	}
+
+	return xlsxPath, jsonPath, nil
+}
+
+// return401SheetName 401 工作表名稱，例如「401_123456789_11301」
+func return401SheetName(r *Return401) string {
+	return fmt.Sprintf("401_%s_%s%s", r.DeclarantTaxId, r.DataYear, r.StartMonth)
+}
+
+// writeReturn401Workbook 產出 401 申報書 Excel
+func writeReturn401Workbook(returns []*Return401, xlsxPath string) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	for i, r := range returns {
+		sheetName := return401SheetName(r)
+		if i == 0 {
+			if err := f.SetSheetName("Sheet1", sheetName); err != nil {
+				return err
+			}
+		} else if _, err := f.NewSheet(sheetName); err != nil {
+			return err
+		}
+		if err := writeReturn401Sheet(f, sheetName, r); err != nil {
+			return err
+		}
+	}
+
+	f.SetActiveSheet(0)
+	if err := f.SaveAs(xlsxPath); err != nil {
+		os.Remove(xlsxPath)
+		return err
+	}
+	return nil
+}
+
+// writeReturn401Sheet 寫入一份 401 申報書：銷項、進項與稅額計算三個區塊
+func writeReturn401Sheet(f *excelize.File, sheetName string, r *Return401) error {
+	w, err := newSheetWriter(f, sheetName)
+	if err != nil {
+		return err
+	}
+	titleStyle, err := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 14},
+	})
+	if err != nil {
+		return err
+	}
+
+	// 申報資訊
+	if err := w.writeRow(titleStyle, "營業人銷售額與稅額申報書（401）試算"); err != nil {
+		return err
+	}
+	if err := w.writeRow(0, "申報營業人稅籍編號", r.DeclarantTaxId); err != nil {
+		return err
+	}
+	if err := w.writeRow(0, "所屬期間", r.Period()); err != nil {
+		return err
+	}
+	w.skipRow()
+
+	// 銷項
+	if err := w.writeRow(w.header, "銷項", "應稅銷售額", "應稅稅額", "零稅率銷售額", "免稅銷售額"); err != nil {
+		return err
+	}
+	for _, line := range append(append([]*Return401SalesLine{}, r.Sales...), r.SalesReturns...) {
+		if err := w.writeRow(w.number, line.Label, line.Taxable.Amount, line.Taxable.Tax, line.ZeroRated, line.Exempt); err != nil {
+			return err
+		}
+	}
+	total := r.SalesTotal
+	if err := w.writeRow(w.total, total.Label, total.Taxable.Amount, total.Taxable.Tax, total.ZeroRated, total.Exempt); err != nil {
+		return err
+	}
+	w.skipRow()
+
+	// 進項
+	if err := w.writeRow(w.header, "進項（得扣抵）", "金額", "稅額"); err != nil {
+		return err
+	}
+	inputRows := []struct {
+		label  string
+		amount Return401Amount
+		sign   int64
+	}{
+		{"進貨及費用", r.Purchases, 1},
+		{"固定資產", r.FixedAssets, 1},
+		{"減：進貨退出及折讓（進貨及費用）", r.PurchaseReturns, -1},
+		{"減：進貨退出及折讓（固定資產）", r.FixedAssetReturns, -1},
+	}
+	var inputTotal Return401Amount
+	for _, item := range inputRows {
+		if err := w.writeRow(w.number, item.label, item.amount.Amount, item.amount.Tax); err != nil {
+			return err
+		}
+		inputTotal.add(item.sign*item.amount.Amount, item.sign*item.amount.Tax)
+	}
+	if err := w.writeRow(w.total, "合計", inputTotal.Amount, inputTotal.Tax); err != nil {
+		return err
+	}
+	if err := w.writeRow(w.number, "不得扣抵（參考）", r.NonDeductible.Amount, r.NonDeductible.Tax); err != nil {
+		return err
+	}
+	w.skipRow()
+
+	// 稅額計算
+	if err := w.writeRow(w.header, "稅額計算", "代號", "金額"); err != nil {
+		return err
+	}
+	calculations := []struct {
+		label string
+		code  string
+		value int64
+	}{
+		{"本期銷項稅額合計", "101", r.OutputTax},
+		{"得扣抵進項稅額合計", "107", r.InputTax},
+		{"上期累積留抵稅額", "108", r.PreviousCredit},
+		{"小計", "110", r.CreditSubtotal},
+		{"本期應實繳稅額", "111", r.PayableTax},
+		{"本期申報留抵稅額", "112", r.CreditTax},
+		{"得退稅限額合計", "113", r.RefundLimit},
+		{"本期應退稅額", "114", r.RefundTax},
+		{"本期累積留抵稅額", "115", r.CarriedForwardCredit},
+	}
+	for _, item := range calculations {
+		if err := w.writeRow(w.number, item.label, item.code, item.value); err != nil {
+			return err
+		}
+	}
+
+	// 未計入的資料
+	if r.SpecialTaxRecords > 0 || r.VoidedRecords > 0 {
+		w.skipRow()
+		if r.SpecialTaxRecords > 0 {
+			if err := w.writeRow(0, fmt.Sprintf("特種稅額計算的資料 %d 筆（格式代號 37、38）未計入，請以 403 申報書申報", r.SpecialTaxRecords)); err != nil {
+				return err
+			}
+		}
+		if r.VoidedRecords > 0 {
+			if err := w.writeRow(0, fmt.Sprintf("作廢或空白未使用的資料 %d 筆（課稅別 F、D）未計入", r.VoidedRecords)); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 設定欄寬
+	return w.setColWidths(36, 16, 16, 16, 16)
+}
@@ -0,0 +1,230 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// newTestReturn401 依測試資料計算 401 申報書
+func newTestReturn401(t *testing.T, records ...TaxRecord) *Return401 {
+	t.Helper()
+	r := newReturn401("123456789", "113", "01", "02")
+	for _, record := range records {
+		r.addRecord(testRecord(t, record))
+	}
+	r.calculate()
+	return r
+}
+
+func TestReturn401TaxIncludedSales(t *testing.T) {
+	r := newTestReturn401(t,
+		// 二聯式、免用發票稅額為 0 時由含稅金額推算：1,050 → 1,000 + 50
+		salesRecord("32", "1", "1050", "0"),
+		salesRecord("36", "1", "105", "0"),
+		salesRecord("34", "1", "210", "0"),
+		// 已填稅額時不再拆分
+		salesRecord("32", "1", "2000", "100"),
+		// 三聯式不拆分
+		salesRecord("31", "1", "1000", "50"),
+	)
+
+	cases := []struct {
+		formatCode string
+		want       Return401Amount
+	}{
+		{formatCode: "31", want: Return401Amount{Amount: 1000, Tax: 50}},
+		{formatCode: "32", want: Return401Amount{Amount: 3000, Tax: 150}},
+		{formatCode: "34", want: Return401Amount{Amount: 200, Tax: 10}},
+		{formatCode: "36", want: Return401Amount{Amount: 100, Tax: 5}},
+	}
+	for _, tc := range cases {
+		if got := r.salesLine(tc.formatCode).Taxable; got != tc.want {
+			t.Errorf("格式代號 %s 應稅 = %+v，應為 %+v", tc.formatCode, got, tc.want)
+		}
+	}
+	if want := (Return401Amount{Amount: 3900, Tax: 195}); r.SalesTotal.Taxable != want {
+		t.Errorf("銷項合計 = %+v，應為 %+v（已扣除退回及折讓）", r.SalesTotal.Taxable, want)
+	}
+	if r.OutputTax != 195 {
+		t.Errorf("本期銷項稅額合計 (101) = %d，應為 195", r.OutputTax)
+	}
+}
+
+func TestReturn401PurchaseReturns(t *testing.T) {
+	customs := func(formatCode, amount, tax string) TaxRecord {
+		return TaxRecord{
+			FormatCode:              formatCode,
+			DeclarantTaxId:          "123456789",
+			DataYear:                "113",
+			DataMonth:               "01",
+			BuyerTaxId:              "04595257",
+			CustomsTaxPaymentNumber: "AA123456789012",
+			TaxBase:                 amount,
+			TaxType:                 "1",
+			TaxAmount:               tax,
+			DeductionCode:           "1",
+		}
+	}
+	r := newTestReturn401(t,
+		purchaseRecord("21", "1", "", "10000", "500"),
+		purchaseRecord("21", "2", "", "20000", "1000"),
+		customs("28", "4000", "200"),
+		purchaseRecord("23", "1", "", "1000", "50"),
+		purchaseRecord("24", "2", "", "2000", "100"),
+		customs("29", "3000", "150"),
+		purchaseRecord("21", "3", "", "6000", "300"),
+		purchaseRecord("23", "4", "", "600", "30"),
+	)
+
+	cases := []struct {
+		name string
+		got  Return401Amount
+		want Return401Amount
+	}{
+		{name: "進貨及費用（含海關繳納證）", got: r.Purchases, want: Return401Amount{Amount: 14000, Tax: 700}},
+		{name: "固定資產", got: r.FixedAssets, want: Return401Amount{Amount: 20000, Tax: 1000}},
+		{name: "進貨退出及折讓（23、29）", got: r.PurchaseReturns, want: Return401Amount{Amount: 4000, Tax: 200}},
+		{name: "固定資產退出及折讓（24）", got: r.FixedAssetReturns, want: Return401Amount{Amount: 2000, Tax: 100}},
+		{name: "不得扣抵（已扣除退出）", got: r.NonDeductible, want: Return401Amount{Amount: 5400, Tax: 270}},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Errorf("%s = %+v，應為 %+v", tc.name, tc.got, tc.want)
+		}
+	}
+	// (700 - 200) + (1,000 - 100)
+	if r.InputTax != 1400 {
+		t.Errorf("得扣抵進項稅額合計 (107) = %d，應為 1400", r.InputTax)
+	}
+}
+
+func TestReturn401FilingFields(t *testing.T) {
+	cases := []struct {
+		name           string
+		outputTax      int64
+		zeroRated      int64
+		purchaseTax    int64
+		fixedAssetTax  int64
+		previousCredit int64
+
+		// 申報書欄位 107、110-115
+		inputTax, subtotal, payable, credit, refundLimit, refund, carried int64
+	}{
+		{
+			name:      "應實繳",
+			outputTax: 5000, purchaseTax: 1000, fixedAssetTax: 400, previousCredit: 600,
+			inputTax: 1400, subtotal: 2000, payable: 3000, credit: 0, refundLimit: 400, refund: 0, carried: 0,
+		},
+		{
+			name:      "留抵全額退稅",
+			outputTax: 500, zeroRated: 10000, purchaseTax: 1000, fixedAssetTax: 400,
+			inputTax: 1400, subtotal: 1400, payable: 0, credit: 900, refundLimit: 900, refund: 900, carried: 0,
+		},
+		{
+			name:      "退稅以限額為上限",
+			outputTax: 500, zeroRated: 2000, purchaseTax: 3000, previousCredit: 200,
+			inputTax: 3000, subtotal: 3200, payable: 0, credit: 2700, refundLimit: 100, refund: 100, carried: 2600,
+		},
+		{
+			name:           "只有上期留抵",
+			previousCredit: 1000,
+			inputTax:       0, subtotal: 1000, payable: 0, credit: 1000, refundLimit: 0, refund: 0, carried: 1000,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newReturn401("123456789", "113", "01", "02")
+			r.salesLine("31").Taxable.Tax = tc.outputTax
+			r.salesLine("31").ZeroRated = tc.zeroRated
+			r.Purchases.Tax = tc.purchaseTax
+			r.FixedAssets.Tax = tc.fixedAssetTax
+			r.SetPreviousCredit(tc.previousCredit)
+
+			fields := []struct {
+				code      string
+				got, want int64
+			}{
+				{"101", r.OutputTax, tc.outputTax},
+				{"107", r.InputTax, tc.inputTax},
+				{"108", r.PreviousCredit, tc.previousCredit},
+				{"110", r.CreditSubtotal, tc.subtotal},
+				{"111", r.PayableTax, tc.payable},
+				{"112", r.CreditTax, tc.credit},
+				{"113", r.RefundLimit, tc.refundLimit},
+				{"114", r.RefundTax, tc.refund},
+				{"115", r.CarriedForwardCredit, tc.carried},
+			}
+			for _, field := range fields {
+				if field.got != field.want {
+					t.Errorf("欄位 %s = %d，應為 %d", field.code, field.got, field.want)
+				}
+			}
+		})
+	}
+}
+
+func TestComputeReturn401GroupsByDeclarantAndPeriod(t *testing.T) {
+	march := salesRecord("31", "1", "2000", "100")
+	march.DataMonth = "03"
+	february := salesRecord("31", "1", "1000", "50")
+	february.DataMonth = "02"
+	other := salesRecord("31", "1", "4000", "200")
+	other.DeclarantTaxId = "012345678"
+	txtFile := writeTestTxt(t, t.TempDir(), "returns.txt",
+		march,
+		salesRecord("31", "1", "1000", "50"),
+		other,
+		february,
+		salesRecord("31", "F", "0", "0"),
+	)
+
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{txtFile}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	returns, err := ComputeReturn401(ctx, [][]*TxtFileInfo{fileInfoList})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		declarant  string
+		startMonth string
+		outputTax  int64
+		voided     int
+	}{
+		{declarant: "012345678", startMonth: "01", outputTax: 200},
+		{declarant: "123456789", startMonth: "01", outputTax: 100, voided: 1},
+		{declarant: "123456789", startMonth: "03", outputTax: 100},
+	}
+	if len(returns) != len(want) {
+		t.Fatalf("共 %d 份申報書，應為 %d 份", len(returns), len(want))
+	}
+	for i, w := range want {
+		r := returns[i]
+		if r.DeclarantTaxId != w.declarant || r.StartMonth != w.startMonth {
+			t.Errorf("第 %d 份 = %s %s，應為 %s %s 月起", i+1, r.DeclarantTaxId, r.Period(), w.declarant, w.startMonth)
+			continue
+		}
+		if r.OutputTax != w.outputTax || r.VoidedRecords != w.voided {
+			t.Errorf("%s %s 銷項稅額 = %d、作廢 %d 筆，應為 %d、%d 筆", r.DeclarantTaxId, r.Period(), r.OutputTax, r.VoidedRecords, w.outputTax, w.voided)
+		}
+	}
+	// AnalyzePeriods 與 403 試算、字軌檢查共用同一次讀取，結果應與 ComputeReturn401 相同
+	periods, err := AnalyzePeriods(ctx, [][]*TxtFileInfo{fileInfoList})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := periods.Returns401(); !reflect.DeepEqual(got, returns) {
+		t.Errorf("AnalyzePeriods 的 401 申報書與 ComputeReturn401 不同")
+	}
+	// 設定上期累積留抵稅額不影響之後取得的申報書
+	periods.Returns401()[0].SetPreviousCredit(1000)
+	if got := periods.Returns401()[0].PreviousCredit; got != 0 {
+		t.Errorf("上期累積留抵稅額 = %d，應為 0", got)
+	}
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"math"
+	"sort"
+)
+
+// 進項分攤註記（直接扣抵法）：1 專供應稅使用、2 專供免稅使用，其餘（含空白）視為共同使用，依比例扣抵
+const (
+	allocationMarkTaxableUse = "1"
+	allocationMarkExemptUse  = "2"
+)
+
+// Return403 兼營營業人（403 申報書）的不得扣抵比例與可扣抵進項稅額試算
+// 銷項與進項合計沿用 401 的計算，另依分攤註記區分進項用途
+type Return403 struct {
+	Return401
+
+	// SpecialTaxSales 特種稅額計算的銷售額（37、38），計入不得扣抵比例的免稅銷售額
+	SpecialTaxSales int64 `json:"specialTaxSales"`
+
+	// 得扣抵進項（扣抵代號 1、2，已扣除進貨退出及折讓）依分攤註記區分
+	TaxableUseInput Return401Amount `json:"taxableUseInput"` // 專供應稅使用
+	ExemptUseInput  Return401Amount `json:"exemptUseInput"`  // 專供免稅使用
+	CommonUseInput  Return401Amount `json:"commonUseInput"`  // 共同使用
+
+	// NonDeductibleRatio 當期不得扣抵比例（百分比，小數點以下無條件捨去）
+	NonDeductibleRatio int64 `json:"nonDeductibleRatio"`
+	// RatioMethodInputTax 比例扣抵法的可扣抵進項稅額
+	RatioMethodInputTax int64 `json:"ratioMethodInputTax"`
+	// DirectMethodInputTax 直接扣抵法的可扣抵進項稅額
+	DirectMethodInputTax int64 `json:"directMethodInputTax"`
+}
+
+// TaxableSales 應稅銷售淨額（含零稅率）
+func (r *Return403) TaxableSales() int64 {
+	return r.SalesTotal.Taxable.Amount + r.SalesTotal.ZeroRated
+}
+
+// ExemptSales 免稅銷售淨額（含特種稅額計算的銷售額）
+func (r *Return403) ExemptSales() int64 {
+	return r.SalesTotal.Exempt + r.SpecialTaxSales
+}
+
+// IsMixed 是否兼營應稅及免稅（有免稅銷售額或進項有分攤註記）
+func (r *Return403) IsMixed() bool {
+	return r.ExemptSales() != 0 || r.TaxableUseInput != (Return401Amount{}) || r.ExemptUseInput != (Return401Amount{})
+}
+
+// calculate 計算不得扣抵比例及兩種方法的可扣抵進項稅額
+func (r *Return403) calculate() {
+	r.Return401.calculate()
+
+	// 不得扣抵比例 = 免稅銷售淨額 ÷ 全部銷售淨額
+	r.NonDeductibleRatio = 0
+	if total := r.TaxableSales() + r.ExemptSales(); total > 0 && r.ExemptSales() > 0 {
+		r.NonDeductibleRatio = r.ExemptSales() * 100 / total
+	}
+
+	// 比例扣抵法：全部得扣抵進項稅額 ×（1 − 不得扣抵比例）
+	r.RatioMethodInputTax = r.deductiblePortion(r.InputTax)
+
+	// 直接扣抵法：專供應稅全額扣抵、專供免稅不得扣抵、共同使用依比例扣抵
+	r.DirectMethodInputTax = r.TaxableUseInput.Tax + r.deductiblePortion(r.CommonUseInput.Tax)
+}
+
+// deductiblePortion 依不得扣抵比例計算可扣抵的稅額（四捨五入）
+func (r *Return403) deductiblePortion(tax int64) int64 {
+	return int64(math.Round(float64(tax) * float64(100-r.NonDeductibleRatio) / 100))
+}
+
+// addRecord 將一筆資料計入試算
+func (r *Return403) addRecord(record *TaxRecord) {
+	r.Return401.addRecord(record)
+	if record.IsVoided() || record.IsBlank() {
+		return
+	}
+
+	amount := parseAmountToInt(record.Amount())
+	tax := parseAmountToInt(record.TaxAmount)
+
+	switch record.FormatCode {
+	case "37", "38":
+		r.SpecialTaxSales += amount
+	case "21", "22", "23", "24", "25", "26", "27", "28", "29":
+		if record.DeductionCode != "1" && record.DeductionCode != "2" {
+			return
+		}
+		// 進貨退出及折讓自進項扣除
+		if record.FormatCode == "23" || record.FormatCode == "24" || record.FormatCode == "29" {
+			amount, tax = -amount, -tax
+		}
+		switch record.AllocationMark {
+		case allocationMarkTaxableUse:
+			r.TaxableUseInput.add(amount, tax)
+		case allocationMarkExemptUse:
+			r.ExemptUseInput.add(amount, tax)
+		default:
+			r.CommonUseInput.add(amount, tax)
+		}
+	}
+}
+
+// return403Totals 依申報營業人與申報期別累計的 403 試算
+type return403Totals struct {
+	returns map[string]*Return403
+}
+
+// newReturn403Totals 建立 return403Totals
+func newReturn403Totals() *return403Totals {
+	return &return403Totals{returns: make(map[string]*Return403)}
+}
+
+// add 將一筆資料計入所屬申報營業人與期別的試算
+func (t *return403Totals) add(_ *TxtFileInfo, record *TaxRecord) {
+	key := returnPeriodKey(record)
+	if _, ok := t.returns[key]; !ok {
+		startMonth, endMonth := reportingPeriod(record.DataMonth)
+		t.returns[key] = &Return403{Return401: *newReturn401(record.DeclarantTaxId, record.DataYear, startMonth, endMonth)}
+	}
+	t.returns[key].addRecord(record)
+}
+
+// calculate 計算各期試算
+func (t *return403Totals) calculate() {
+	for _, r := range t.returns {
+		r.calculate()
+	}
+}
+
+// returns401 各期的 401 申報書（依申報營業人、期別排序），複製後回傳，設定上期累積留抵稅額不影響 403 試算
+func (t *return403Totals) returns401() []*Return401 {
+	keys := make([]string, 0, len(t.returns))
+	for key := range t.returns {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*Return401, 0, len(keys))
+	for _, key := range keys {
+		r := t.returns[key].Return401
+		result = append(result, &r)
+	}
+	return result
+}
+
+// mixedReturns 回傳 periods 中兼營應稅及免稅的期別（依申報營業人、期別排序）
+// periods 為 returnPeriodKey 的集合，即一個 Excel 內有資料的申報營業人與期別
+func (t *return403Totals) mixedReturns(periods map[string]bool) []*Return403 {
+	keys := make([]string, 0, len(periods))
+	for key := range periods {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]*Return403, 0)
+	for _, key := range keys {
+		if r, ok := t.returns[key]; ok && r.IsMixed() {
+			result = append(result, r)
+		}
+	}
+	return result
+}
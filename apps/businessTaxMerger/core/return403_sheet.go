@@ -0,0 +1,92 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// return403SheetName 403 試算工作表名稱
+const return403SheetName = "403試算"
+
+// writeReturn403Sheet 寫入 403 試算工作表：各申報營業人、期別的不得扣抵比例，
+// 以及比例扣抵法與直接扣抵法的可扣抵進項稅額與應納（留抵）稅額
+func writeReturn403Sheet(f *excelize.File, returns []*Return403) error {
+	w, err := newSheetWriter(f, return403SheetName)
+	if err != nil {
+		return err
+	}
+
+	for i, r := range returns {
+		if i > 0 {
+			w.skipRow()
+		}
+
+		// 銷售淨額與不得扣抵比例
+		if err := w.writeRow(w.header, fmt.Sprintf("%s %s", r.DeclarantTaxId, r.Period()), "金額"); err != nil {
+			return err
+		}
+		salesRows := []struct {
+			label string
+			value int64
+		}{
+			{"應稅銷售淨額", r.SalesTotal.Taxable.Amount},
+			{"零稅率銷售淨額", r.SalesTotal.ZeroRated},
+			{"免稅銷售淨額", r.SalesTotal.Exempt},
+			{"特種稅額計算銷售額", r.SpecialTaxSales},
+		}
+		for _, item := range salesRows {
+			if err := w.writeRow(w.number, item.label, item.value); err != nil {
+				return err
+			}
+		}
+		if err := w.writeRow(w.total, "當期不得扣抵比例（%）", r.NonDeductibleRatio); err != nil {
+			return err
+		}
+		w.skipRow()
+
+		// 得扣抵進項依分攤註記區分
+		if err := w.writeRow(w.header, "得扣抵進項（依分攤註記）", "金額", "稅額"); err != nil {
+			return err
+		}
+		inputRows := []struct {
+			label  string
+			amount Return401Amount
+		}{
+			{fmt.Sprintf("專供應稅使用（%s）", allocationMarkTaxableUse), r.TaxableUseInput},
+			{fmt.Sprintf("專供免稅使用（%s）", allocationMarkExemptUse), r.ExemptUseInput},
+			{"共同使用（其他）", r.CommonUseInput},
+		}
+		for _, item := range inputRows {
+			if err := w.writeRow(w.number, item.label, item.amount.Amount, item.amount.Tax); err != nil {
+				return err
+			}
+		}
+		if err := w.writeRow(w.total, "合計", "", r.InputTax); err != nil {
+			return err
+		}
+		w.skipRow()
+
+		// 兩種方法的比較
+		if err := w.writeRow(w.header, "扣抵方法", "可扣抵進項稅額", "不得扣抵進項稅額", "銷項稅額", "應納（負數為留抵）稅額"); err != nil {
+			return err
+		}
+		methods := []struct {
+			label    string
+			inputTax int64
+		}{
+			{"比例扣抵法", r.RatioMethodInputTax},
+			{"直接扣抵法", r.DirectMethodInputTax},
+		}
+		for _, method := range methods {
+			if err := w.writeRow(w.number,
+				method.label, method.inputTax, r.InputTax-method.inputTax, r.OutputTax, r.OutputTax-method.inputTax,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 設定欄寬
+	return w.setColWidths(30, 16, 18, 14, 22)
+}
@@ -0,0 +1,155 @@
+package core
+
+import (
+	"testing"
+)
+
+// salesRecord 銷項三聯式發票測試資料（申報營業人 123456789，113 年 01 月）
+func salesRecord(formatCode, taxType, amount, tax string) TaxRecord {
+	return TaxRecord{
+		FormatCode:         formatCode,
+		DeclarantTaxId:     "123456789",
+		DataYear:           "113",
+		DataMonth:          "01",
+		BuyerTaxId:         "04595257",
+		SellerTaxId:        "22099131",
+		InvoicePrefix:      "AB",
+		InvoiceStartNumber: "00000001",
+		SalesAmount:        amount,
+		TaxType:            taxType,
+		TaxAmount:          tax,
+	}
+}
+
+// purchaseRecord 進項三聯式發票測試資料（申報營業人 123456789，113 年 01 月）
+func purchaseRecord(formatCode, deductionCode, allocationMark, amount, tax string) TaxRecord {
+	return TaxRecord{
+		FormatCode:         formatCode,
+		DeclarantTaxId:     "123456789",
+		DataYear:           "113",
+		DataMonth:          "01",
+		BuyerTaxId:         "04595257",
+		SellerTaxId:        "22099131",
+		InvoicePrefix:      "CD",
+		InvoiceStartNumber: "00000001",
+		SalesAmount:        amount,
+		TaxType:            "1",
+		TaxAmount:          tax,
+		DeductionCode:      deductionCode,
+		AllocationMark:     allocationMark,
+	}
+}
+
+// newTestReturn403 依測試資料計算 403 試算
+func newTestReturn403(t *testing.T, records ...TaxRecord) *Return403 {
+	t.Helper()
+	r := &Return403{Return401: *newReturn401("123456789", "113", "01", "02")}
+	for _, record := range records {
+		r.addRecord(testRecord(t, record))
+	}
+	r.calculate()
+	return r
+}
+
+func TestReturn403NonDeductibleRatio(t *testing.T) {
+	special := salesRecord("37", "1", "100", "2")
+	special.SpecialTaxRate = "1"
+
+	cases := []struct {
+		name    string
+		records []TaxRecord
+		want    int64
+	}{
+		{
+			name:    "只有應稅",
+			records: []TaxRecord{salesRecord("31", "1", "1000", "50")},
+			want:    0,
+		},
+		{
+			name:    "無條件捨去（23.07%）",
+			records: []TaxRecord{salesRecord("31", "1", "1000000", "50000"), salesRecord("31", "3", "300000", "0")},
+			want:    23,
+		},
+		{
+			name:    "無條件捨去而非四捨五入（66.67%）",
+			records: []TaxRecord{salesRecord("31", "1", "200", "10"), salesRecord("31", "3", "400", "0")},
+			want:    66,
+		},
+		{
+			name:    "零稅率計入應稅",
+			records: []TaxRecord{salesRecord("31", "2", "700", "0"), salesRecord("31", "1", "200", "10"), salesRecord("31", "3", "100", "0")},
+			want:    10,
+		},
+		{
+			name:    "特種稅額計算銷售額計入免稅",
+			records: []TaxRecord{salesRecord("31", "1", "900", "45"), special},
+			want:    10,
+		},
+		{
+			name:    "銷貨退回自免稅銷售額扣除",
+			records: []TaxRecord{salesRecord("31", "1", "500", "25"), salesRecord("31", "3", "600", "0"), salesRecord("33", "3", "100", "0")},
+			want:    50,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := newTestReturn403(t, tc.records...)
+			if r.NonDeductibleRatio != tc.want {
+				t.Errorf("不得扣抵比例 = %d%%，應為 %d%%", r.NonDeductibleRatio, tc.want)
+			}
+		})
+	}
+}
+
+func TestReturn403DeductionMethods(t *testing.T) {
+	// 不得扣抵比例 300,000 ÷ 1,300,000 = 23%
+	r := newTestReturn403(t,
+		salesRecord("31", "1", "1000000", "50000"),
+		salesRecord("31", "3", "300000", "0"),
+		purchaseRecord("21", "1", allocationMarkTaxableUse, "100000", "5000"),
+		purchaseRecord("21", "2", allocationMarkExemptUse, "40000", "2000"),
+		purchaseRecord("21", "1", "", "200000", "10000"),
+		purchaseRecord("23", "1", "", "20000", "1000"),
+		// 不得扣抵的進項不計入任何一種方法
+		purchaseRecord("21", "3", "", "60000", "3000"),
+	)
+
+	if r.NonDeductibleRatio != 23 {
+		t.Fatalf("不得扣抵比例 = %d%%，應為 23%%", r.NonDeductibleRatio)
+	}
+	if r.InputTax != 16000 {
+		t.Errorf("得扣抵進項稅額 = %d，應為 16000", r.InputTax)
+	}
+	if want := (Return401Amount{Amount: 180000, Tax: 9000}); r.CommonUseInput != want {
+		t.Errorf("共同使用進項 = %+v，應為 %+v（已扣除進貨退出）", r.CommonUseInput, want)
+	}
+	// 比例扣抵法：16,000 × 77% = 12,320
+	if r.RatioMethodInputTax != 12320 {
+		t.Errorf("比例扣抵法可扣抵稅額 = %d，應為 12320", r.RatioMethodInputTax)
+	}
+	// 直接扣抵法：專供應稅 5,000 全額 + 共同使用 9,000 × 77% = 6,930
+	if r.DirectMethodInputTax != 11930 {
+		t.Errorf("直接扣抵法可扣抵稅額 = %d，應為 11930", r.DirectMethodInputTax)
+	}
+	if !r.IsMixed() {
+		t.Error("有免稅銷售額應視為兼營")
+	}
+}
+
+func TestReturn403IsMixed(t *testing.T) {
+	taxableOnly := newTestReturn403(t,
+		salesRecord("31", "1", "1000", "50"),
+		purchaseRecord("21", "1", "", "500", "25"),
+	)
+	if taxableOnly.IsMixed() {
+		t.Error("只有應稅銷售額且進項無分攤註記時不應視為兼營")
+	}
+
+	marked := newTestReturn403(t,
+		salesRecord("31", "1", "1000", "50"),
+		purchaseRecord("21", "1", allocationMarkExemptUse, "500", "25"),
+	)
+	if !marked.IsMixed() {
+		t.Error("進項有分攤註記時應視為兼營")
+	}
+}
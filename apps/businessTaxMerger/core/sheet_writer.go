@@ -0,0 +1,89 @@
+package core
+
+import (
+	"github.com/xuri/excelize/v2"
+)
+
+// sheetWriter 逐列寫入彙總、試算等報表工作表（資料工作表以 StreamWriter 寫入，不使用此類別）
+type sheetWriter struct {
+	f     *excelize.File
+	sheet string
+	row   int
+
+	// header 標題 (橘色背景 + 粗體 + 置中 + 邊框)
+	header int
+	// number 數字 (#,##0)
+	number int
+	// total 合計 (粗體 + 上框線 + #,##0)
+	total int
+	// finding 檢查結果 (紅字 + #,##0)
+	finding int
+}
+
+// newSheetWriter 建立工作表（已存在時沿用）並準備標題、數字、合計與檢查結果樣式，從第一列開始寫入
+func newSheetWriter(f *excelize.File, sheetName string) (*sheetWriter, error) {
+	if _, err := f.NewSheet(sheetName); err != nil {
+		return nil, err
+	}
+
+	w := &sheetWriter{f: f, sheet: sheetName, row: 1}
+	var err error
+	if w.header, err = newHeaderStyle(f); err != nil {
+		return nil, err
+	}
+	if w.number, err = f.NewStyle(&excelize.Style{
+		NumFmt: 3, // 數字格式: #,##0
+	}); err != nil {
+		return nil, err
+	}
+	if w.total, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		NumFmt: 3,
+		Border: []excelize.Border{
+			{Type: "top", Color: "000000", Style: 1},
+		},
+	}); err != nil {
+		return nil, err
+	}
+	if w.finding, err = f.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Color: "#9C0006"},
+		NumFmt: 3,
+	}); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// writeRow 從 A 欄起寫入一列並套用 style（0 為預設樣式），寫完後移到下一列
+func (w *sheetWriter) writeRow(style int, values ...interface{}) error {
+	for i, value := range values {
+		cell, _ := excelize.CoordinatesToCellName(i+1, w.row)
+		if err := w.f.SetCellValue(w.sheet, cell, value); err != nil {
+			return err
+		}
+	}
+	row := w.row
+	w.row++
+	if len(values) == 0 {
+		return nil
+	}
+	first, _ := excelize.CoordinatesToCellName(1, row)
+	last, _ := excelize.CoordinatesToCellName(len(values), row)
+	return w.f.SetCellStyle(w.sheet, first, last, style)
+}
+
+// skipRow 空一列
+func (w *sheetWriter) skipRow() {
+	w.row++
+}
+
+// setColWidths 從 A 欄起依序設定欄寬
+func (w *sheetWriter) setColWidths(widths ...float64) error {
+	for i, width := range widths {
+		col, _ := excelize.ColumnNumberToName(i + 1)
+		if err := w.f.SetColWidth(w.sheet, col, col, width); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// summaryRange 彙總登錄（格式代號 26、27）以發票字軌申報的號碼範圍
+type summaryRange struct {
+	start      int
+	end        int
+	fileName   string
+	lineNumber int
+	file       *TxtFileInfo
+}
+
+// rangeInvoice 個別申報的發票號碼與位置，比對是否落在彙總登錄範圍內
+type rangeInvoice struct {
+	number        int
+	invoiceNumber string
+	fileName      string
+	lineNumber    int
+	file          *TxtFileInfo
+
+	// order 讀取順序（驗證問題依此排列）
+	order int
+}
+
+// rangeOverlap 落在彙總登錄範圍內的個別申報發票
+type rangeOverlap struct {
+	invoice rangeInvoice
+	r       *summaryRange
+}
+
+// location 資料位置描述，例如「a.txt 第 3 行」
+func (s *summaryRange) location() string {
+	return fmt.Sprintf("%s 第 %d 行", s.fileName, s.lineNumber)
+}
+
+// summaryInvoiceRange 彙總登錄的起訖號（發票(起)號碼 至 BusinessNumber）
+// 以其他憑證號碼申報、號碼無法解析或訖號小於起號時回傳 false（起訖號本身的錯誤由 ValidateRecord 檢查）
+func summaryInvoiceRange(record *TaxRecord) (int, int, bool) {
+	if record.Kind != KindSummary || record.InvoicePrefix == "" {
+		return 0, 0, false
+	}
+	start, err := strconv.Atoi(record.InvoiceStartNumber)
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := strconv.Atoi(record.BusinessNumber)
+	if err != nil || end < start {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// validateSummaryRange 檢查彙總登錄的起訖號與彙總張數：訖號不可小於起號，張數應等於起訖號之間的張數
+func validateSummaryRange(record *TaxRecord) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	addIssue := func(field, value, reason string) {
+		issues = append(issues, ValidationIssue{
+			FileName:   record.SourceFileName,
+			LineNumber: record.LineNumber,
+			Field:      field,
+			Value:      value,
+			Reason:     reason,
+		})
+	}
+
+	start, startErr := strconv.Atoi(record.InvoiceStartNumber)
+	end, endErr := strconv.Atoi(record.BusinessNumber)
+	sheets, sheetsErr := strconv.Atoi(record.TotalSheets)
+	if startErr != nil || endErr != nil || sheetsErr != nil {
+		// 非數字已列為結構錯誤
+		return issues
+	}
+	if end < start {
+		addIssue("BusinessNumber", record.BusinessNumber, "彙總登錄訖號不可小於起號")
+		return issues
+	}
+	if count := end - start + 1; sheets != count {
+		addIssue("TotalSheets", record.TotalSheets, fmt.Sprintf("彙總張數應等於起訖號 %08d-%08d 之間的張數 %d", start, end, count))
+	}
+	return issues
+}
+
+// summaryTrackKey 彙總登錄與個別發票比對的字軌鍵：申報營業人 + 年度 + 字軌
+func summaryTrackKey(record *TaxRecord) string {
+	return record.DeclarantTaxId + "_" + record.DataYear + "_" + record.InvoicePrefix
+}
+
+// CheckSummaryRanges 展開彙總登錄的號碼範圍，檢查同一申報營業人、年度與字軌下：
+// 範圍是否與其他彙總登錄重疊，以及範圍內的號碼是否另有個別申報（退回及折讓證明單除外）
+// 回傳所有驗證問題，並在各檔案記錄有問題的資料列（匯出時加入驗證報告）；須在分配（拆分片段）之前呼叫
+// 同時需要重複發票檢查時改用 CheckFiles，兩項檢查共用同一次讀取
+func CheckSummaryRanges(ctx context.Context, fileInfoList []*TxtFileInfo) ([]ValidationIssue, error) {
+	checker := newSummaryRangeChecker(fileInfoList)
+	if err := scanFiles(ctx, fileInfoList, checker.add); err != nil {
+		return nil, err
+	}
+	checker.finish()
+	return checker.issues(nil), nil
+}
+
+// summaryRangeChecker 逐筆收集彙總登錄的範圍與個別申報的發票號碼，全部讀取後以 finish 比對
+type summaryRangeChecker struct {
+	files []*TxtFileInfo
+
+	// ranges 各字軌的彙總登錄範圍（筆數通常不多），finish 後依起號排序
+	ranges map[string][]*summaryRange
+
+	// invoices 各字軌個別申報的發票，finish 比對後釋放
+	invoices map[string][]rangeInvoice
+	count    int
+
+	// keys 有彙總登錄的字軌（排序後）
+	keys []string
+
+	// overlaps 落在範圍內的個別申報發票（依讀取順序）
+	overlaps []rangeOverlap
+}
+
+// newSummaryRangeChecker 建立 summaryRangeChecker
+func newSummaryRangeChecker(fileInfoList []*TxtFileInfo) *summaryRangeChecker {
+	return &summaryRangeChecker{
+		files:    fileInfoList,
+		ranges:   make(map[string][]*summaryRange),
+		invoices: make(map[string][]rangeInvoice),
+	}
+}
+
+// add 收集一筆彙總登錄的範圍或個別申報的發票號碼
+func (c *summaryRangeChecker) add(fileInfo *TxtFileInfo, record *TaxRecord) {
+	if start, end, ok := summaryInvoiceRange(record); ok {
+		key := summaryTrackKey(record)
+		c.ranges[key] = append(c.ranges[key], &summaryRange{
+			start:      start,
+			end:        end,
+			fileName:   record.SourceFileName,
+			lineNumber: record.LineNumber,
+			file:       fileInfo,
+		})
+		return
+	}
+	if record.Kind != KindInvoice || record.IsBlank() || record.IsAllowance() {
+		return
+	}
+	number, err := strconv.Atoi(record.InvoiceStartNumber)
+	if err != nil {
+		return
+	}
+	key := summaryTrackKey(record)
+	c.invoices[key] = append(c.invoices[key], rangeInvoice{
+		number:        number,
+		invoiceNumber: record.InvoiceNumber(),
+		fileName:      record.SourceFileName,
+		lineNumber:    record.LineNumber,
+		file:          fileInfo,
+		order:         c.count,
+	})
+	c.count++
+}
+
+// finish 依起號排序各字軌的範圍，找出落在範圍內的個別申報發票
+func (c *summaryRangeChecker) finish() {
+	c.keys = make([]string, 0, len(c.ranges))
+	for key := range c.ranges {
+		c.keys = append(c.keys, key)
+	}
+	sort.Strings(c.keys)
+
+	for _, key := range c.keys {
+		trackRanges := c.ranges[key]
+		sort.SliceStable(trackRanges, func(a, b int) bool {
+			return trackRanges[a].start < trackRanges[b].start
+		})
+
+		// 範圍依起號排序，只需檢查起號不大於發票號碼的範圍
+		for _, invoice := range c.invoices[key] {
+			count := sort.Search(len(trackRanges), func(i int) bool { return trackRanges[i].start > invoice.number })
+			for _, r := range trackRanges[:count] {
+				if invoice.number <= r.end {
+					c.overlaps = append(c.overlaps, rangeOverlap{invoice: invoice, r: r})
+				}
+			}
+		}
+	}
+	sort.SliceStable(c.overlaps, func(a, b int) bool {
+		return c.overlaps[a].invoice.order < c.overlaps[b].invoice.order
+	})
+	c.invoices = nil
+}
+
+// issues 回傳所有驗證問題，並在各檔案重新記錄有問題的資料列
+// dropped 為已刪除的資料（檔案 → 行號），不列入比對
+func (c *summaryRangeChecker) issues(dropped map[*TxtFileInfo]map[int]bool) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	addIssue := func(file *TxtFileInfo, issue ValidationIssue) {
+		file.markRangeIssue(issue)
+		issues = append(issues, issue)
+	}
+	for _, fileInfo := range c.files {
+		fileInfo.rangeIssues = nil
+	}
+
+	// 彙總登錄之間的重疊
+	for _, key := range c.keys {
+		trackRanges := c.ranges[key]
+		covering := trackRanges[0]
+		for _, r := range trackRanges[1:] {
+			if r.start <= covering.end {
+				addIssue(r.file, ValidationIssue{
+					FileName:   r.fileName,
+					LineNumber: r.lineNumber,
+					Field:      "InvoiceStartNumber",
+					Value:      fmt.Sprintf("%08d-%08d", r.start, r.end),
+					Reason:     fmt.Sprintf("彙總登錄範圍與 %s 的彙總登錄範圍 %08d-%08d 重疊", covering.location(), covering.start, covering.end),
+				})
+			}
+			if r.end > covering.end {
+				covering = r
+			}
+		}
+	}
+
+	// 個別申報的發票落在彙總登錄範圍內
+	overlapCount := make(map[*summaryRange]int)
+	firstOverlap := make(map[*summaryRange]string)
+	for _, overlap := range c.overlaps {
+		invoice, r := overlap.invoice, overlap.r
+		if dropped[invoice.file][invoice.lineNumber] {
+			continue
+		}
+		addIssue(invoice.file, ValidationIssue{
+			FileName:   invoice.fileName,
+			LineNumber: invoice.lineNumber,
+			Field:      "InvoiceStartNumber",
+			Value:      invoice.invoiceNumber,
+			Reason:     fmt.Sprintf("發票已包含在 %s 的彙總登錄範圍 %08d-%08d 內，不可再個別申報", r.location(), r.start, r.end),
+		})
+		if overlapCount[r] == 0 {
+			firstOverlap[r] = fmt.Sprintf("%s 第 %d 行", invoice.fileName, invoice.lineNumber)
+		}
+		overlapCount[r]++
+	}
+
+	for _, key := range c.keys {
+		for _, r := range c.ranges[key] {
+			if overlapCount[r] == 0 {
+				continue
+			}
+			addIssue(r.file, ValidationIssue{
+				FileName:   r.fileName,
+				LineNumber: r.lineNumber,
+				Field:      "BusinessNumber",
+				Value:      fmt.Sprintf("%08d-%08d", r.start, r.end),
+				Reason:     fmt.Sprintf("彙總登錄範圍內有 %d 張發票另有個別申報（第一筆為 %s）", overlapCount[r], firstOverlap[r]),
+			})
+		}
+	}
+	return issues
+}
+
+// markRangeIssue 記錄彙總登錄範圍的驗證問題（片段與原始檔共用記錄）
+func (info *TxtFileInfo) markRangeIssue(issue ValidationIssue) {
+	if info.rangeIssues == nil {
+		info.rangeIssues = make(map[int][]ValidationIssue)
+	}
+	info.rangeIssues[issue.LineNumber] = append(info.rangeIssues[issue.LineNumber], issue)
+}
+
+// recordRangeIssues 指定行號的彙總登錄範圍驗證問題
+func (info *TxtFileInfo) recordRangeIssues(lineNumber int) []ValidationIssue {
+	if info.source != nil {
+		return info.source.recordRangeIssues(lineNumber)
+	}
+	return info.rangeIssues[lineNumber]
+}
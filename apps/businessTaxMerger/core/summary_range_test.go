@@ -0,0 +1,184 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// summaryRecord 進項彙總登錄測試資料（字軌 AB，起號至訖號，彙總張數 sheets）
+func summaryRecord(start, end, sheets int) TaxRecord {
+	return TaxRecord{
+		FormatCode:         "26",
+		DeclarantTaxId:     "123456789",
+		DataYear:           "113",
+		DataMonth:          "01",
+		BusinessNumber:     fmt.Sprintf("%08d", end),
+		TotalSheets:        fmt.Sprintf("%04d", sheets),
+		InvoicePrefix:      "AB",
+		InvoiceStartNumber: fmt.Sprintf("%08d", start),
+		SalesAmount:        "100000",
+		TaxType:            "1",
+		TaxAmount:          "5000",
+		DeductionCode:      "1",
+	}
+}
+
+func TestValidateSummaryRange(t *testing.T) {
+	cases := []struct {
+		name   string
+		record TaxRecord
+		// want 有問題的欄位（nil 表示沒有問題）
+		want []string
+	}{
+		{name: "張數等於起訖號之間的張數", record: summaryRecord(100, 149, 50)},
+		{name: "單張", record: summaryRecord(100, 100, 1)},
+		{name: "張數少於起訖號之間的張數", record: summaryRecord(100, 149, 49), want: []string{"TotalSheets"}},
+		{name: "張數多於起訖號之間的張數", record: summaryRecord(100, 149, 51), want: []string{"TotalSheets"}},
+		{name: "訖號小於起號", record: summaryRecord(149, 100, 50), want: []string{"BusinessNumber"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range validateSummaryRange(testRecord(t, tc.record)) {
+				got = append(got, issue.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("有問題的欄位 = %v，應為 %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCheckSummaryRanges(t *testing.T) {
+	invoice := func(formatCode, prefix string, number int) TaxRecord {
+		record := purchaseRecord(formatCode, "1", "", "1000", "50")
+		record.InvoicePrefix = prefix
+		record.InvoiceStartNumber = fmt.Sprintf("%08d", number)
+		return record
+	}
+
+	dir := t.TempDir()
+	a := writeTestTxt(t, dir, "a.txt",
+		summaryRecord(100, 149, 50),
+		summaryRecord(140, 199, 60), // 與第 1 行重疊
+		summaryRecord(300, 309, 10),
+		invoice("21", "AB", 120), // 落在第 1 行的範圍內
+		invoice("23", "AB", 125), // 退回及折讓證明單不比對
+		invoice("21", "CD", 120), // 其他字軌
+		invoice("21", "AB", 200), // 範圍外
+	)
+	b := writeTestTxt(t, dir, "b.txt",
+		invoice("21", "AB", 305), // 跨檔落在 a.txt 第 3 行的範圍內
+	)
+
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{a, b}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	issues, err := CheckSummaryRanges(ctx, fileInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		got = append(got, fmt.Sprintf("%s:%d:%s", issue.FileName, issue.LineNumber, issue.Field))
+	}
+	sort.Strings(got)
+	want := []string{
+		"a.txt:1:BusinessNumber",     // 範圍內有個別申報的發票
+		"a.txt:2:InvoiceStartNumber", // 彙總登錄之間重疊
+		"a.txt:3:BusinessNumber",
+		"a.txt:4:InvoiceStartNumber", // 個別申報的發票
+		"b.txt:1:InvoiceStartNumber",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("驗證問題 = %v，應為 %v", got, want)
+	}
+
+	// 匯出時依行號取得問題
+	marked := []struct {
+		info       *TxtFileInfo
+		lineNumber int
+		want       int
+	}{
+		{fileInfoList[0], 1, 1},
+		{fileInfoList[0], 2, 1},
+		{fileInfoList[0], 5, 0},
+		{fileInfoList[0], 6, 0},
+		{fileInfoList[0], 7, 0},
+		{fileInfoList[1], 1, 1},
+	}
+	for _, m := range marked {
+		if got := len(m.info.recordRangeIssues(m.lineNumber)); got != m.want {
+			t.Errorf("%s 第 %d 行有 %d 個問題，應為 %d 個", m.info.FileName, m.lineNumber, got, m.want)
+		}
+	}
+}
+
+func TestCheckFilesSkipsDroppedDuplicates(t *testing.T) {
+	invoice := func(sequence int) TaxRecord {
+		record := purchaseRecord("21", "1", "", "1000", "50")
+		record.InvoicePrefix = "AB"
+		record.InvoiceStartNumber = "00000120"
+		record.SequenceNumber = fmt.Sprintf("%07d", sequence)
+		return record
+	}
+	dir := t.TempDir()
+	a := writeTestTxt(t, dir, "a.txt", summaryRecord(100, 149, 50), invoice(1))
+	b := writeTestTxt(t, dir, "b.txt", invoice(2)) // 與 a.txt 第 2 行完全重複
+
+	ctx := context.Background()
+	fileInfoList, err := AnalyzeFiles(ctx, []string{a, b}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ReleaseFiles(fileInfoList)
+
+	checks, err := CheckFiles(ctx, fileInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	locations := func(issues []ValidationIssue) []string {
+		got := make([]string, 0, len(issues))
+		for _, issue := range issues {
+			got = append(got, fmt.Sprintf("%s:%d:%s", issue.FileName, issue.LineNumber, issue.Field))
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	// 刪除前與 CheckSummaryRanges 相同
+	want, err := CheckSummaryRanges(ctx, fileInfoList)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := locations(checks.SummaryRangeIssues()); !reflect.DeepEqual(got, locations(want)) {
+		t.Errorf("刪除前的驗證問題 = %v，應為 %v", got, locations(want))
+	}
+	if checks.Duplicates.CopyCount() != 1 {
+		t.Fatalf("可刪除 %d 筆，應為 1 筆", checks.Duplicates.CopyCount())
+	}
+
+	if _, err := DropExactDuplicates(ctx, checks.Duplicates); err != nil {
+		t.Fatal(err)
+	}
+	issues := checks.SummaryRangeIssues()
+	if got, want := locations(issues), []string{"a.txt:1:BusinessNumber", "a.txt:2:InvoiceStartNumber"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("刪除後的驗證問題 = %v，應為 %v", got, want)
+	}
+	for _, issue := range issues {
+		if issue.Field == "BusinessNumber" && !strings.Contains(issue.Reason, "有 1 張發票") {
+			t.Errorf("彙總登錄的問題說明 = %q，應只計入未刪除的 1 張", issue.Reason)
+		}
+	}
+	if got := len(fileInfoList[1].recordRangeIssues(1)); got != 0 {
+		t.Errorf("已刪除的 b.txt 第 1 行有 %d 個問題，應為 0 個", got)
+	}
+}
@@ -0,0 +1,241 @@
+package core
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// summarySheetName 彙總工作表名稱
+const summarySheetName = "彙總"
+
+// controlTotal 筆數與金額小計
+type controlTotal struct {
+	Count     int
+	Amount    int64 // 銷售金額（海關繳納證為營業稅稅基）
+	TaxAmount int64
+}
+
+// add 加入一筆資料
+func (t *controlTotal) add(record *TaxRecord) {
+	t.Count++
+	t.Amount += parseAmountToInt(record.Amount())
+	t.TaxAmount += parseAmountToInt(record.TaxAmount)
+}
+
+// remove 扣除一筆資料
+func (t *controlTotal) remove(record *TaxRecord) {
+	t.Count--
+	t.Amount -= parseAmountToInt(record.Amount())
+	t.TaxAmount -= parseAmountToInt(record.TaxAmount)
+}
+
+// recordTotal 計入合計的筆數與金額，另計作廢及空白未使用的筆數
+type recordTotal struct {
+	controlTotal
+	Voided int
+}
+
+// add 加入一筆資料（作廢及空白未使用只計筆數）
+func (t *recordTotal) add(record *TaxRecord) {
+	if record.IsVoided() || record.IsBlank() {
+		t.Voided++
+		return
+	}
+	t.controlTotal.add(record)
+}
+
+// remove 扣除一筆資料（刪除重複資料時使用）
+func (t *recordTotal) remove(record *TaxRecord) {
+	if record.IsVoided() || record.IsBlank() {
+		t.Voided--
+		return
+	}
+	t.controlTotal.remove(record)
+}
+
+// merge 加入另一份小計
+func (t *recordTotal) merge(other recordTotal) {
+	t.Count += other.Count
+	t.Amount += other.Amount
+	t.TaxAmount += other.TaxAmount
+	t.Voided += other.Voided
+}
+
+// sourceTotal 來源檔案小計：匯出時寫入的筆數與金額、結構錯誤的行數，
+// 以及分析原始檔時記錄的筆數與金額（Analyzed，與匯出時寫入的資料核對）
+type sourceTotal struct {
+	recordTotal
+	ParseErrors int
+	Analyzed    recordTotal
+}
+
+// reconciled 匯出時寫入的筆數與金額是否與分析時相符
+func (t *sourceTotal) reconciled() bool {
+	return t.recordTotal == t.Analyzed
+}
+
+// reconcileNote 核對結果說明
+func (t *sourceTotal) reconcileNote() string {
+	if t.reconciled() {
+		return "相符"
+	}
+	return fmt.Sprintf("與分析時不符：分析時 %d 筆、%s %d、%s %d、作廢及空白未使用 %d 筆",
+		t.Analyzed.Count, amountHeader, t.Analyzed.Amount, FieldLabel("TaxAmount"), t.Analyzed.TaxAmount, t.Analyzed.Voided)
+}
+
+// summaryKey 彙總分類：格式代號 × 課稅別 × 扣抵代號
+type summaryKey struct {
+	FormatCode    string
+	TaxType       string
+	DeductionCode string
+}
+
+// controlTotals 匯出時累計的控制總數（依分類與來源檔案）
+type controlTotals struct {
+	byKey      map[summaryKey]*controlTotal
+	bySource   map[string]*sourceTotal
+	sources    []string // 來源檔案依出現順序
+	grandTotal controlTotal
+	periods    map[string]bool // 有資料（含作廢及空白未使用）的申報營業人與期別（returnPeriodKey），用於選取 403 試算與字軌檢查
+	voided     []voidedInvoice // 作廢及空白未使用發票（不計入上列小計與合計）
+}
+
+// newControlTotals 建立控制總數
+func newControlTotals() *controlTotals {
+	return &controlTotals{
+		byKey:    make(map[summaryKey]*controlTotal),
+		bySource: make(map[string]*sourceTotal),
+		sources:  make([]string, 0),
+		periods:  make(map[string]bool),
+	}
+}
+
+// add 記錄資料所屬申報營業人與期別，並將資料計入分類、來源檔案與合計
+// 作廢及空白未使用發票只計入來源檔案的筆數，另列於作廢及空白發票工作表
+func (c *controlTotals) add(source string, record *TaxRecord) {
+	c.addSource(source).add(record)
+	c.periods[returnPeriodKey(record)] = true
+	if record.IsVoided() || record.IsBlank() {
+		c.voided = append(c.voided, newVoidedInvoice(record))
+		return
+	}
+
+	key := summaryKey{
+		FormatCode:    record.FormatCode,
+		TaxType:       record.TaxType,
+		DeductionCode: record.DeductionCode,
+	}
+	if _, ok := c.byKey[key]; !ok {
+		c.byKey[key] = &controlTotal{}
+	}
+	c.byKey[key].add(record)
+
+	c.grandTotal.add(record)
+}
+
+// addSource 取得來源檔案小計，第一次出現時加入（匯出時每個來源檔案都先加入，沒有可計入合計的資料也會列出）
+func (c *controlTotals) addSource(source string) *sourceTotal {
+	if total, ok := c.bySource[source]; ok {
+		return total
+	}
+	total := &sourceTotal{}
+	c.bySource[source] = total
+	c.sources = append(c.sources, source)
+	return total
+}
+
+// sourceLabel 來源檔案名稱（片段加上段數與行號範圍）
+func sourceLabel(fileInfo *TxtFileInfo) string {
+	if fileInfo.IsPart() {
+		return fileInfo.FileName + " [" + fileInfo.PartDescription() + "]"
+	}
+	if fileInfo.GroupBy != GroupByNone {
+		return fileInfo.FileName + " [" + fileInfo.GroupLabel() + "]"
+	}
+	return fileInfo.FileName
+}
+
+// writeSummarySheet 寫入彙總工作表
+// 上方為格式代號 × 課稅別 × 扣抵代號的筆數與金額，下方為各來源檔案小計，兩者皆有合計列
+// 作廢及空白未使用發票不計入，只在上方合計列下列出筆數；來源檔案另列作廢及空白未使用與結構錯誤的行數，
+// 並與分析原始檔時記錄的筆數與金額核對，不符時以紅字標示（暫存資料遺漏或重複寫入）
+func writeSummarySheet(f *excelize.File, totals *controlTotals) error {
+	w, err := newSheetWriter(f, summarySheetName)
+	if err != nil {
+		return err
+	}
+
+	// 依格式代號、課稅別、扣抵代號排序
+	keys := make([]summaryKey, 0, len(totals.byKey))
+	for key := range totals.byKey {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].FormatCode != keys[b].FormatCode {
+			return keys[a].FormatCode < keys[b].FormatCode
+		}
+		if keys[a].TaxType != keys[b].TaxType {
+			return keys[a].TaxType < keys[b].TaxType
+		}
+		return keys[a].DeductionCode < keys[b].DeductionCode
+	})
+
+	if err := w.writeRow(w.header,
+		FieldLabel("FormatCode"), FieldLabel("TaxType"), FieldLabel("DeductionCode"), "筆數", amountHeader, FieldLabel("TaxAmount"),
+	); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		total := totals.byKey[key]
+		if err := w.writeRow(w.number, key.FormatCode, key.TaxType, key.DeductionCode, total.Count, total.Amount, total.TaxAmount); err != nil {
+			return err
+		}
+	}
+	if err := w.writeRow(w.total, "合計", "", "", totals.grandTotal.Count, totals.grandTotal.Amount, totals.grandTotal.TaxAmount); err != nil {
+		return err
+	}
+	if len(totals.voided) > 0 {
+		if err := w.writeRow(w.number, "作廢及空白未使用（不計入合計）", "", "", len(totals.voided)); err != nil {
+			return err
+		}
+	}
+
+	// 各來源檔案小計（缺少檔案時可立即看出），每個來源與分析時的筆數與金額核對
+	w.skipRow()
+	if err := w.writeRow(w.header,
+		"來源檔案", "", "", "筆數", amountHeader, FieldLabel("TaxAmount"), "作廢及空白未使用", "結構錯誤", "與分析時核對",
+	); err != nil {
+		return err
+	}
+	var sourcesTotal sourceTotal
+	mismatched := 0
+	for _, source := range totals.sources {
+		total := totals.bySource[source]
+		style := w.number
+		if !total.reconciled() {
+			style = w.finding
+			mismatched++
+		}
+		if err := w.writeRow(style,
+			source, "", "", total.Count, total.Amount, total.TaxAmount, total.Voided, total.ParseErrors, total.reconcileNote(),
+		); err != nil {
+			return err
+		}
+		sourcesTotal.merge(total.recordTotal)
+		sourcesTotal.ParseErrors += total.ParseErrors
+	}
+	note := "全部相符"
+	if mismatched > 0 {
+		note = fmt.Sprintf("%d 個來源與分析時不符", mismatched)
+	}
+	if err := w.writeRow(w.total,
+		"合計", "", "", sourcesTotal.Count, sourcesTotal.Amount, sourcesTotal.TaxAmount, sourcesTotal.Voided, sourcesTotal.ParseErrors, note,
+	); err != nil {
+		return err
+	}
+
+	// 設定欄寬
+	return w.setColWidths(14, 10, 10, 12, 18, 16, 16, 10, 60)
+}
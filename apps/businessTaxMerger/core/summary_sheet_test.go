@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// dropSpillLine 直接刪除暫存檔中的一行（模擬分析後資料遺漏）
+func dropSpillLine(t *testing.T, info *TxtFileInfo, position int) {
+	t.Helper()
+	data, err := os.ReadFile(info.SpillPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(string(data), "\n")
+	lines = append(lines[:position], lines[position+1:]...)
+	if err := os.WriteFile(info.SpillPath, []byte(strings.Join(lines, "")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// summarySourceRows 彙總工作表中來源檔案小計的各列（含合計列）
+func summarySourceRows(t *testing.T, fileGroup []*TxtFileInfo) [][]string {
+	t.Helper()
+	f := excelize.NewFile()
+	defer f.Close()
+
+	styles, err := newDataStyles(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals := newControlTotals()
+	if _, _, err := writeDataSheet(context.Background(), f, dataSheetName, fileGroup, 0, DefaultColumnProfile, DefaultValidationOptions(), styles, totals); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSummarySheet(f, totals); err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := f.GetRows(summarySheetName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		if len(row) > 0 && row[0] == "來源檔案" {
+			return rows[i+1:]
+		}
+	}
+	t.Fatal("彙總工作表沒有來源檔案小計")
+	return nil
+}
+
+func TestSummarySheetReconcilesSources(t *testing.T) {
+	second := salesRecord("31", "1", "2000", "100")
+	second.InvoiceStartNumber = "00000002"
+	voided := salesRecord("31", "F", "0", "0")
+	voided.InvoiceStartNumber = "00000003"
+	records := []TaxRecord{salesRecord("31", "1", "1000", "50"), second, second, voided}
+
+	cases := []struct {
+		name string
+		// prepare 分析後、匯出前的處理，回傳要匯出的檔案
+		prepare    func(t *testing.T, fileInfoList []*TxtFileInfo) []*TxtFileInfo
+		sources    int
+		mismatched bool
+	}{
+		{
+			name: "未變動",
+			prepare: func(t *testing.T, fileInfoList []*TxtFileInfo) []*TxtFileInfo {
+				return fileInfoList
+			},
+			sources: 1,
+		},
+		{
+			name: "暫存資料遺漏一筆",
+			prepare: func(t *testing.T, fileInfoList []*TxtFileInfo) []*TxtFileInfo {
+				dropSpillLine(t, fileInfoList[0], 1)
+				return fileInfoList
+			},
+			sources:    1,
+			mismatched: true,
+		},
+		{
+			name: "刪除完全重複資料後",
+			prepare: func(t *testing.T, fileInfoList []*TxtFileInfo) []*TxtFileInfo {
+				ctx := context.Background()
+				report, err := FindDuplicates(ctx, fileInfoList)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if dropped, err := DropExactDuplicates(ctx, report); err != nil || dropped != 1 {
+					t.Fatalf("刪除 %d 筆（%v），應為 1 筆", dropped, err)
+				}
+				return fileInfoList
+			},
+			sources: 1,
+		},
+		{
+			name: "拆分片段",
+			prepare: func(t *testing.T, fileInfoList []*TxtFileInfo) []*TxtFileInfo {
+				allocation, err := ValidateAndAllocateFiles(context.Background(), fileInfoList, 3, 2, StrategyNextFit, true)
+				if err != nil {
+					t.Fatal(err)
+				}
+				parts := make([]*TxtFileInfo, 0)
+				for _, excelFiles := range allocation {
+					parts = append(parts, excelFiles...)
+				}
+				return parts
+			},
+			sources: 2,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			txtFile := writeTestTxt(t, t.TempDir(), "summary.txt", records...)
+			fileInfoList, err := AnalyzeFiles(context.Background(), []string{txtFile}, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer ReleaseFiles(fileInfoList)
+
+			rows := summarySourceRows(t, tc.prepare(t, fileInfoList))
+			if len(rows) != tc.sources+1 {
+				t.Fatalf("來源檔案小計共 %d 列，應為 %d 列（含合計）", len(rows), tc.sources+1)
+			}
+			const noteColumn = 8
+			for _, row := range rows[:tc.sources] {
+				note := row[noteColumn]
+				if tc.mismatched != strings.HasPrefix(note, "與分析時不符") {
+					t.Errorf("%s 核對結果 = %q", row[0], note)
+				}
+			}
+			want := "全部相符"
+			if tc.mismatched {
+				want = "1 個來源與分析時不符"
+			}
+			if total := rows[tc.sources][noteColumn]; total != want {
+				t.Errorf("合計列核對結果 = %q，應為 %q", total, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package core
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// DefaultTaxTolerance 預設的營業稅額容許差額（元，每張發票）
+const DefaultTaxTolerance int64 = 1
+
+// specialTaxRates 特種稅額稅率代號（位置 79）對應的稅率，用於格式代號 37、38
+// 代號依財政部「營業人進銷項資料媒體申報檔案格式」的特種稅額稅率欄位，
+// 稅率依加值型及非加值型營業稅法第 11 條（金融保險業）、第 12 條（特種飲食業）
+var specialTaxRates = map[string]float64{
+	"1": 0.02, // 信託投資業、證券業、期貨業、票券業、典當業經營本業（第 11 條第 1 項第 3 款）
+	"2": 0.15, // 夜總會、有娛樂節目之餐飲店（第 12 條第 1 款）
+	"3": 0.25, // 酒家及有陪侍服務之茶室、咖啡廳、酒吧（第 12 條第 2 款）
+	"4": 0.01, // 保險業的再保費收入（第 11 條第 1 項第 2 款但書）
+	"5": 0.05, // 銀行業、保險業經營銀行、保險本業（第 11 條第 1 項第 2 款）
+}
+
+// taxSheetCount 一筆資料包含的發票張數：彙總登錄為彙總張數，其餘為 1
+func taxSheetCount(record *TaxRecord) int64 {
+	if record.Kind == KindSummary {
+		if sheets, err := strconv.ParseInt(record.TotalSheets, 10, 64); err == nil && sheets > 0 {
+			return sheets
+		}
+	}
+	return 1
+}
+
+// validateTaxAmount 依課稅別檢查營業稅額：應稅為銷售金額 × 5%（特種稅額計算依特種稅額稅率），
+// 零稅率與免稅應為 0；含稅金額申報的格式（二聯式、免用發票）稅額為 0 時不檢查
+// tolerance 為每張發票容許的四捨五入差額，彙總登錄依彙總張數累加
+func validateTaxAmount(record *TaxRecord, tolerance int64) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	addIssue := func(field, value, reason string) {
+		issues = append(issues, ValidationIssue{
+			FileName:   record.SourceFileName,
+			LineNumber: record.LineNumber,
+			Field:      field,
+			Value:      value,
+			Reason:     reason,
+		})
+	}
+
+	tax := parseAmountToInt(record.TaxAmount)
+	switch record.TaxType {
+	case "2":
+		if tax != 0 {
+			addIssue("TaxAmount", record.TaxAmount, "零稅率資料營業稅額應為 0")
+		}
+		return issues
+	case "3":
+		if tax != 0 {
+			addIssue("TaxAmount", record.TaxAmount, "免稅資料營業稅額應為 0")
+		}
+		return issues
+	case "1":
+	default:
+		return issues
+	}
+
+	rate, rateLabel := taxRate, "5%"
+	if record.FormatCode == "37" || record.FormatCode == "38" {
+		if record.SpecialTaxRate == "" {
+			addIssue("SpecialTaxRate", record.SpecialTaxRate, "特種稅額計算的資料應填寫特種稅額稅率")
+			return issues
+		}
+		specialRate, ok := specialTaxRates[record.SpecialTaxRate]
+		if !ok {
+			// 無法辨識的代號不檢查稅額
+			return issues
+		}
+		rate = specialRate
+		rateLabel = strconv.FormatFloat(specialRate*100, 'f', -1, 64) + "%"
+	}
+	if taxIncludedFormatCodes[record.FormatCode] && tax == 0 {
+		return issues
+	}
+
+	expected := int64(math.Round(float64(parseAmountToInt(record.Amount())) * rate))
+	tolerance *= taxSheetCount(record)
+	if difference := tax - expected; difference > tolerance || difference < -tolerance {
+		addIssue("TaxAmount", record.TaxAmount,
+			fmt.Sprintf("營業稅額應為%s × %s = %d（容許差額 %d 元）", FieldLabel(amountField(record)), rateLabel, expected, tolerance))
+	}
+	return issues
+}
+
+// amountField 金額欄位名稱：海關繳納證為營業稅稅基，其餘為銷售金額
+func amountField(record *TaxRecord) string {
+	if record.Kind == KindCustoms {
+		return "TaxBase"
+	}
+	return "SalesAmount"
+}
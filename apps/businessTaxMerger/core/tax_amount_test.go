@@ -0,0 +1,73 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateTaxAmount(t *testing.T) {
+	special := func(code, amount, tax string) TaxRecord {
+		record := salesRecord("37", "1", amount, tax)
+		record.SpecialTaxRate = code
+		return record
+	}
+	summary := func(sheets int, amount, tax string) TaxRecord {
+		record := summaryRecord(12345600, 12345699, sheets)
+		record.SalesAmount, record.TaxAmount = amount, tax
+		return record
+	}
+	customs := TaxRecord{
+		FormatCode:              "28",
+		DeclarantTaxId:          "123456789",
+		DataYear:                "113",
+		DataMonth:               "01",
+		BuyerTaxId:              "04595257",
+		CustomsTaxPaymentNumber: "AA123456789012",
+		TaxBase:                 "200000",
+		TaxType:                 "1",
+		TaxAmount:               "10000",
+		DeductionCode:           "1",
+	}
+
+	cases := []struct {
+		name      string
+		record    TaxRecord
+		tolerance int64
+		// want 有問題的欄位（nil 表示沒有問題）
+		want []string
+	}{
+		{name: "應稅 5%", record: salesRecord("31", "1", "10000", "500"), tolerance: 1},
+		{name: "應稅四捨五入在容許差額內", record: salesRecord("31", "1", "10010", "500"), tolerance: 1},
+		{name: "應稅四捨五入超過容許差額", record: salesRecord("31", "1", "10010", "500"), tolerance: 0, want: []string{"TaxAmount"}},
+		{name: "應稅稅額錯誤", record: salesRecord("31", "1", "10000", "498"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "海關繳納證依稅基計算", record: customs, tolerance: 1},
+		{name: "零稅率稅額為 0", record: salesRecord("31", "2", "10000", "0"), tolerance: 1},
+		{name: "零稅率有稅額", record: salesRecord("31", "2", "10000", "10"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "免稅稅額為 0", record: salesRecord("31", "3", "10000", "0"), tolerance: 1},
+		{name: "免稅有稅額", record: salesRecord("31", "3", "10000", "5"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "二聯式含稅金額稅額為 0 不檢查", record: salesRecord("32", "1", "10500", "0"), tolerance: 1},
+		{name: "二聯式分開填寫稅額", record: salesRecord("32", "1", "10000", "400"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "特種稅額 2%", record: special("1", "10000", "200"), tolerance: 1},
+		{name: "特種稅額 15%", record: special("2", "10000", "1500"), tolerance: 1},
+		{name: "特種稅額 25%", record: special("3", "10000", "2500"), tolerance: 1},
+		{name: "特種稅額 1%（再保費收入）", record: special("4", "10000", "100"), tolerance: 1},
+		{name: "特種稅額 5%（銀行、保險本業）", record: special("5", "10000", "500"), tolerance: 1},
+		{name: "特種稅額誤用一般稅率", record: special("1", "10000", "500"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "特種稅額未填稅率代號", record: special("", "10000", "500"), tolerance: 1, want: []string{"SpecialTaxRate"}},
+		{name: "無法辨識的稅率代號不檢查", record: special("9", "10000", "123"), tolerance: 1},
+		{name: "彙總登錄依張數累加容許差額", record: summary(100, "100000", "5090"), tolerance: 1},
+		{name: "彙總登錄超過累加的容許差額", record: summary(100, "100000", "5101"), tolerance: 1, want: []string{"TaxAmount"}},
+		{name: "彙總登錄單張不累加", record: summary(1, "100000", "5002"), tolerance: 1, want: []string{"TaxAmount"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, issue := range validateTaxAmount(testRecord(t, tc.record), tc.tolerance) {
+				got = append(got, issue.Field)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("有問題的欄位 = %v，應為 %v", got, tc.want)
+			}
+		})
+	}
+}
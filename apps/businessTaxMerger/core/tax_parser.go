@@ -1,17 +1,56 @@
 package core
 
 import (
-	"fmt"
 	"strings"
 )
 
+// 進項格式代號 21-29
+var inputFormatCodes = map[string]bool{
+	"21": true, // 進項三聯式、電子計算機統一發票
+	"22": true, // 進項二聯式收銀機統一發票、載有稅額之其他憑證
+	"23": true, // 三聯式、電子計算機、三聯式收銀機統一發票之進貨退出或折讓證明單
+	"24": true, // 二聯式收銀機統一發票及載有稅額之其他憑證之進貨退出或折讓證明單
+	"25": true, // 進項三聯式收銀機統一發票、一般稅額計算之電子發票、公用事業收據
+	"26": true, // 彙總登錄：三聯式、電子計算機、三聯式收銀機統一發票
+	"27": true, // 彙總登錄：二聯式收銀機統一發票、載有稅額之其他憑證
+	"28": true, // 海關代徵營業稅繳納證
+	"29": true, // 海關退還溢繳營業稅申報單
+}
+
+// 銷項格式代號 31-38
+var outputFormatCodes = map[string]bool{
+	"31": true, // 銷項三聯式、電子計算機統一發票
+	"32": true, // 銷項二聯式、二聯式收銀機統一發票
+	"33": true, // 三聯式、電子計算機、三聯式收銀機統一發票之銷貨退回或折讓證明單
+	"34": true, // 二聯式、二聯式收銀機統一發票之銷貨退回或折讓證明單
+	"35": true, // 銷項三聯式收銀機統一發票、一般稅額計算之電子發票
+	"36": true, // 銷項免用統一發票
+	"37": true, // 銷項特種稅額計算之統一發票
+	"38": true, // 銷項特種稅額計算之銷貨退回或折讓證明單
+}
+
+// IsInputFormatCode 是否為進項格式代號
+func IsInputFormatCode(formatCode string) bool {
+	return inputFormatCodes[formatCode]
+}
+
+// IsOutputFormatCode 是否為銷項格式代號
+func IsOutputFormatCode(formatCode string) bool {
+	return outputFormatCodes[formatCode]
+}
+
 // ParseLine 解析單行資料
 // line: 原始行資料
 // lineNumber: 行號
 // sourceFileName: 來源檔案名稱
+// 結構檢查（長度、格式代號、數字及代碼欄位）失敗時回傳 ParseErrors
 func ParseLine(line string, lineNumber int, sourceFileName string) (*TaxRecord, error) {
 	if len(strings.TrimSpace(line)) == 0 {
-		return nil, fmt.Errorf("資料行不可為空")
+		return nil, ParseErrors{{
+			FileName:   sourceFileName,
+			LineNumber: lineNumber,
+			Reason:     "資料行不可為空",
+		}}
 	}
 
 	record := &TaxRecord{
@@ -20,81 +59,146 @@ func ParseLine(line string, lineNumber int, sourceFileName string) (*TaxRecord,
 		SourceFileName: sourceFileName,
 	}
 
-	// 基本資訊
-	record.FormatCode = safeSubstring(line, 0, 2)              // 1-2
-	record.DeclarantTaxId = safeSubstring(line, 2, 9)          // 3-11
-	record.SequenceNumber = safeSubstring(line, 11, 7)         // 12-18
-
-	// 資料所屬年月
-	record.DataYear = safeSubstring(line, 18, 3)               // 19-21
-	record.DataMonth = safeSubstring(line, 21, 2)              // 22-23
-
-	// 買受人/營業/銷售人統編號 (欄位共用 24-31)
-	record.BuyerTaxId = safeSubstring(line, 23, 8)             // 24-31
-	record.BusinessNumber = safeSubstring(line, 23, 8)         // 24-31 (共用)
-
-	record.SellerTaxId = safeSubstring(line, 31, 8)            // 32-39
-
-	// 統一發票
-	record.InvoicePrefix = safeSubstring(line, 39, 2)          // 40-41
-	record.InvoiceStartNumber = safeSubstring(line, 41, 8)     // 42-49
+	// 規格的欄位位置以位元組計算（Big5），先轉為規格位元組再截取
+	data := toSpecBytes(line)
 
-	// 彙總張數 (欄位共用 32-35)
-	record.TotalSheets = safeSubstring(line, 31, 4)            // 32-35
-	record.Blank1 = safeSubstring(line, 35, 4)                 // 36-39
+	// 基本資訊（所有格式共用）
+	record.FormatCode = sliceField(data, 0, 2)      // 1-2
+	record.DeclarantTaxId = sliceField(data, 2, 9)  // 3-11
+	record.SequenceNumber = sliceField(data, 11, 7) // 12-18
 
-	// 其他憑證號碼/公用事業流水號 (欄位共用 40-49)
-	record.OtherVoucherNumber = safeSubstring(line, 39, 10)    // 40-49
-	record.UtilitySequenceNumber = safeSubstring(line, 39, 10) // 40-49 (共用)
-
-	// 海關代徵
-	record.Blank2 = safeSubstring(line, 31, 4)                 // 32-35
-	record.CustomsTaxPaymentNumber = safeSubstring(line, 35, 14) // 36-49
+	// 資料所屬年月
+	record.DataYear = sliceField(data, 18, 3)  // 19-21
+	record.DataMonth = sliceField(data, 21, 2) // 22-23
+
+	// 位置 24-49 依格式代號決定欄位
+	record.Kind = detectRecordKind(record.FormatCode, sliceField(data, 39, 2))
+	switch record.Kind {
+	case KindInvoice:
+		if IsOutputFormatCode(record.FormatCode) && sliceField(data, 61, 1) == "D" {
+			record.BusinessNumber = sliceField(data, 23, 8) // 24-31 空白未使用發票訖號
+		} else {
+			record.BuyerTaxId = sliceField(data, 23, 8) // 24-31
+		}
+		record.SellerTaxId = sliceField(data, 31, 8)        // 32-39
+		record.InvoicePrefix = sliceField(data, 39, 2)      // 40-41
+		record.InvoiceStartNumber = sliceField(data, 41, 8) // 42-49
+
+	case KindOtherVoucher:
+		record.BuyerTaxId = sliceField(data, 23, 8)          // 24-31
+		record.SellerTaxId = sliceField(data, 31, 8)         // 32-39
+		record.OtherVoucherNumber = sliceField(data, 39, 10) // 40-49
+
+	case KindUtilityVoucher:
+		record.BuyerTaxId = sliceField(data, 23, 8)             // 24-31
+		record.SellerTaxId = sliceField(data, 31, 8)            // 32-39
+		record.UtilitySequenceNumber = sliceField(data, 39, 10) // 40-49
+
+	case KindSummary:
+		record.BusinessNumber = sliceField(data, 23, 8) // 24-31 發票訖號
+		record.TotalSheets = sliceField(data, 31, 4)    // 32-35
+		record.Blank1 = sliceField(data, 35, 4)         // 36-39
+		if isInvoiceTrack(sliceField(data, 39, 2)) {
+			record.InvoicePrefix = sliceField(data, 39, 2)      // 40-41
+			record.InvoiceStartNumber = sliceField(data, 41, 8) // 42-49
+		} else {
+			record.OtherVoucherNumber = sliceField(data, 39, 10) // 40-49
+		}
+
+	case KindCustoms:
+		record.BuyerTaxId = sliceField(data, 23, 8)               // 24-31
+		record.Blank2 = sliceField(data, 31, 4)                   // 32-35
+		record.CustomsTaxPaymentNumber = sliceField(data, 35, 14) // 36-49
+	}
 
-	// 金額 (欄位共用 50-61)
-	record.SalesAmount = safeSubstring(line, 49, 12)           // 50-61
-	record.TaxBase = safeSubstring(line, 49, 12)               // 50-61 (共用)
+	// 金額：海關繳納證為營業稅稅基，其餘為銷售金額 (50-61)
+	if record.Kind == KindCustoms {
+		record.TaxBase = sliceField(data, 49, 12)
+	} else {
+		record.SalesAmount = sliceField(data, 49, 12)
+	}
 
 	// 課稅別
-	record.TaxType = safeSubstring(line, 61, 1)                // 62-62
+	record.TaxType = sliceField(data, 61, 1) // 62-62
 
 	// 營業稅額
-	record.TaxAmount = safeSubstring(line, 62, 10)             // 63-72
+	record.TaxAmount = sliceField(data, 62, 10) // 63-72
 
 	// 扣抵代號
-	record.DeductionCode = safeSubstring(line, 72, 1)          // 73-73
-	record.Blank3 = safeSubstring(line, 73, 5)                 // 74-78
+	record.DeductionCode = sliceField(data, 72, 1) // 73-73
+	record.Blank3 = sliceField(data, 73, 5)        // 74-78
 
 	// 特種稅額類稅率
-	record.SpecialTaxRate = safeSubstring(line, 78, 1)         // 79-79
+	record.SpecialTaxRate = sliceField(data, 78, 1) // 79-79
 
-	// 彙加性記/分攤性記 (欄位共用 80-80)
-	record.AggregationMark = safeSubstring(line, 79, 1)        // 80-80
-	record.AllocationMark = safeSubstring(line, 79, 1)         // 80-80 (共用)
+	// 位置 80：銷項為彙加註記，進項為分攤註記
+	if record.IsOutput() {
+		record.AggregationMark = sliceField(data, 79, 1)
+	} else if record.IsInput() {
+		record.AllocationMark = sliceField(data, 79, 1)
+	}
 
 	// 通關方式註記
-	record.CustomsClearanceMark = safeSubstring(line, 80, 1)   // 81-81
+	record.CustomsClearanceMark = sliceField(data, 80, 1) // 81-81
+
+	if errs := checkStructure(record, data); len(errs) > 0 {
+		return nil, errs
+	}
 
 	return record, nil
 }
 
-// safeSubstring 安全的字串截取（處理超出範圍的情況）
-func safeSubstring(source string, startIndex, length int) string {
-	if len(source) == 0 {
-		return ""
+// detectRecordKind 依格式代號判定資料類別
+// 22、24 可能是二聯式收銀機發票或其他憑證；25 可能是統一發票或公用事業收據，以位置 40-41（track）是否為字軌判斷
+func detectRecordKind(formatCode string, track string) RecordKind {
+	switch formatCode {
+	case "21", "23", "31", "32", "33", "34", "35", "36", "37", "38":
+		return KindInvoice
+	case "22", "24":
+		if isInvoiceTrack(track) {
+			return KindInvoice
+		}
+		return KindOtherVoucher
+	case "25":
+		if isInvoiceTrack(track) {
+			return KindInvoice
+		}
+		return KindUtilityVoucher
+	case "26", "27":
+		return KindSummary
+	case "28", "29":
+		return KindCustoms
+	default:
+		return KindUnknown
 	}
+}
 
-	// 將 string 轉為 rune 陣列以正確處理 UTF-8 字元
-	runes := []rune(source)
+// isInvoiceTrack 是否為發票字軌（兩個大寫英文字母）
+func isInvoiceTrack(value string) bool {
+	if len(value) != 2 {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 'A' || value[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
 
-	if startIndex >= len(runes) {
+// sliceField 依位元組位置截取欄位（處理超出範圍的情況）
+// data: 規格位元組（Big5）
+// startIndex: 起始位元組（0 起算）
+// length: 欄位位元組長度
+func sliceField(data []byte, startIndex, length int) string {
+	if startIndex >= len(data) {
 		return ""
 	}
 
 	endIndex := startIndex + length
-	if endIndex > len(runes) {
-		endIndex = len(runes)
+	if endIndex > len(data) {
+		endIndex = len(data)
 	}
 
-	return strings.TrimSpace(string(runes[startIndex:endIndex]))
+	return strings.TrimSpace(fromSpecBytes(data[startIndex:endIndex]))
 }
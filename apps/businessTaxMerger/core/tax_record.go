@@ -26,7 +26,7 @@ type TaxRecord struct {
 	// BuyerTaxId 買受人統一編號 X(008) - 位置 24-31 (欄位共用)
 	BuyerTaxId string
 
-	// BusinessNumber 發票訖號 9(008) - 位置 24-31 (欄位共用)
+	// BusinessNumber 發票訖號 9(008) - 位置 24-31 (欄位共用：彙總登錄、空白未使用發票)
 	BusinessNumber string
 
 	// SellerTaxId 銷售人統一編號 X(008) - 位置 32-39
@@ -106,6 +106,110 @@ type TaxRecord struct {
 
 	// SourceFileName 來源檔案名稱
 	SourceFileName string
+
+	// ===== 解析結果 =====
+
+	// Kind 資料類別（依格式代號決定共用欄位的意義）
+	Kind RecordKind
+}
+
+// RecordKind 資料類別
+// 同一段位置在不同格式代號下代表不同欄位，解析時依格式代號判定類別後只填入該類別定義的欄位
+type RecordKind int
+
+const (
+	// KindUnknown 無法辨識的格式代號
+	KindUnknown RecordKind = iota
+	// KindInvoice 統一發票（含折讓證明單）
+	KindInvoice
+	// KindOtherVoucher 載有稅額之其他憑證
+	KindOtherVoucher
+	// KindUtilityVoucher 公用事業開立之收據
+	KindUtilityVoucher
+	// KindSummary 彙總登錄
+	KindSummary
+	// KindCustoms 海關代徵營業稅繳納證 / 海關退還溢繳營業稅申報單
+	KindCustoms
+)
+
+// String 資料類別名稱
+func (k RecordKind) String() string {
+	switch k {
+	case KindInvoice:
+		return "統一發票"
+	case KindOtherVoucher:
+		return "其他憑證"
+	case KindUtilityVoucher:
+		return "公用事業收據"
+	case KindSummary:
+		return "彙總登錄"
+	case KindCustoms:
+		return "海關繳納證"
+	default:
+		return "未知"
+	}
+}
+
+// IsInput 是否為進項資料（格式代號 21-29）
+func (r *TaxRecord) IsInput() bool {
+	return IsInputFormatCode(r.FormatCode)
+}
+
+// IsOutput 是否為銷項資料（格式代號 31-38）
+func (r *TaxRecord) IsOutput() bool {
+	return IsOutputFormatCode(r.FormatCode)
+}
+
+// IsVoided 是否為作廢發票（課稅別 F）
+func (r *TaxRecord) IsVoided() bool {
+	return r.TaxType == "F"
+}
+
+// IsBlank 是否為空白未使用發票（課稅別 D），起號為發票(起)號碼、訖號為 BusinessNumber
+func (r *TaxRecord) IsBlank() bool {
+	return r.TaxType == "D"
+}
+
+// IsAllowance 是否為退回及折讓證明單（格式代號 23、24、33、34、38），號碼為原發票號碼
+func (r *TaxRecord) IsAllowance() bool {
+	switch r.FormatCode {
+	case "23", "24", "33", "34", "38":
+		return true
+	}
+	return false
+}
+
+// InvoiceNumber 發票號碼（字軌 + 號碼）
+func (r *TaxRecord) InvoiceNumber() string {
+	return r.InvoicePrefix + r.InvoiceStartNumber
+}
+
+// VoucherNumber 依資料類別取得憑證號碼
+// 統一發票為字軌 + 號碼，其他憑證、公用事業收據、海關繳納證則為各自的號碼欄位
+func (r *TaxRecord) VoucherNumber() string {
+	switch r.Kind {
+	case KindOtherVoucher:
+		return r.OtherVoucherNumber
+	case KindUtilityVoucher:
+		return r.UtilitySequenceNumber
+	case KindCustoms:
+		return r.CustomsTaxPaymentNumber
+	case KindSummary:
+		if r.OtherVoucherNumber != "" {
+			return r.OtherVoucherNumber
+		}
+		return r.InvoiceNumber()
+	default:
+		return r.InvoiceNumber()
+	}
+}
+
+// Amount 依資料類別取得金額（海關繳納證為營業稅稅基，其餘為銷售金額）
+func (r *TaxRecord) Amount() string {
+	if r.Kind == KindCustoms {
+		return r.TaxBase
+	}
+	return r.SalesAmount
 }
 
 // TxtFileInfo TXT 檔案資訊
@@ -113,14 +217,63 @@ type TxtFileInfo struct {
 	FilePath  string
 	FileName  string
 	LineCount int
+	Encoding  FileEncoding
+
+	// RecordCount 有效資料筆數（不含空白行與結構錯誤行），分配 Excel 時以此計算列數
+	RecordCount int
+
+	// ParseErrors 分析時收集的結構錯誤
+	ParseErrors []ParseError
+
+	// SpillPath 有效資料的本機暫存檔路徑
+	SpillPath string
+
+	// analyzed 分析原始檔時記錄的筆數與金額，匯出時與實際寫入的資料核對
+	// 分組、拆分片段時依所屬資料計算，刪除重複資料時扣除
+	analyzed recordTotal
+
+	// ===== 拆分片段（超過最大列數的檔案拆到連續的 Excel，未拆分時皆為 0） =====
+
+	// PartNumber 片段序號（1 起算）
+	PartNumber int
+
+	// PartCount 原始檔拆成的片段數
+	PartCount int
+
+	// RecordOffset 片段第一筆在原始檔有效資料中的位置（0 起算）
+	RecordOffset int
+
+	// StartLine 片段第一筆資料在原始檔的行號
+	StartLine int
+
+	// EndLine 片段最後一筆資料在原始檔的行號
+	EndLine int
+
+	// source 片段所屬的原始檔（片段與原始檔共用暫存檔）
+	source *TxtFileInfo
+
+	// ===== 分組（GroupFiles 依欄位拆開後，同組資料只放在同組的 Excel） =====
+
+	// GroupBy 分組依據
+	GroupBy GroupBy
+
+	// GroupValue 分組值（例如申報營業人稅籍編號）
+	GroupValue string
+
+	// duplicates 重複發票的資料列（行號 → 所屬重複發票），由 FindDuplicates 記錄
+	duplicates map[int]*DuplicateGroup
+
+	// rangeIssues 彙總登錄範圍的驗證問題（行號 → 問題），由 CheckSummaryRanges 記錄
+	rangeIssues map[int][]ValidationIssue
 }
 
 // NewTxtFileInfo 建立 TxtFileInfo
-func NewTxtFileInfo(filePath string, lineCount int) *TxtFileInfo {
+func NewTxtFileInfo(filePath string, lineCount int, encoding FileEncoding) *TxtFileInfo {
 	return &TxtFileInfo{
 		FilePath:  filePath,
 		FileName:  getFileName(filePath),
 		LineCount: lineCount,
+		Encoding:  encoding,
 	}
 }
 
@@ -133,3 +286,85 @@ func getFileName(filePath string) string {
 	}
 	return filePath
 }
+
+// fieldLabels TaxRecord 欄位中文名稱
+var fieldLabels = map[string]string{
+	"FormatCode":              "格式代號",
+	"DeclarantTaxId":          "申報營業人稅籍編號",
+	"SequenceNumber":          "流水號",
+	"DataYear":                "資料所屬年度",
+	"DataMonth":               "資料所屬月份",
+	"BuyerTaxId":              "買受人統一編號",
+	"BusinessNumber":          "發票訖號",
+	"SellerTaxId":             "銷售人統一編號",
+	"InvoicePrefix":           "發票字軌",
+	"InvoiceStartNumber":      "發票(起)號碼",
+	"TotalSheets":             "彙總張數",
+	"Blank1":                  "空白欄位(36-39)",
+	"Blank2":                  "空白欄位(32-35)",
+	"OtherVoucherNumber":      "其他憑證號碼",
+	"UtilitySequenceNumber":   "公用事業載具流水號",
+	"CustomsTaxPaymentNumber": "海關代徵營業稅繳納證號碼",
+	"SalesAmount":             "銷售金額",
+	"TaxBase":                 "營業稅稅基",
+	"TaxType":                 "課稅別",
+	"TaxAmount":               "營業稅額",
+	"DeductionCode":           "扣抵代號",
+	"Blank3":                  "空白欄位(74-78)",
+	"SpecialTaxRate":          "特種稅額稅率",
+	"AggregationMark":         "彙加註記",
+	"AllocationMark":          "分攤註記",
+	"CustomsClearanceMark":    "通關方式註記",
+	"SourceFileName":          "來源檔案",
+	"LineNumber":              "行號",
+}
+
+// recordFields TaxRecord 字串欄位存取表（欄位名 → 欄位指標）
+var recordFields = map[string]func(r *TaxRecord) *string{
+	"FormatCode":              func(r *TaxRecord) *string { return &r.FormatCode },
+	"DeclarantTaxId":          func(r *TaxRecord) *string { return &r.DeclarantTaxId },
+	"SequenceNumber":          func(r *TaxRecord) *string { return &r.SequenceNumber },
+	"DataYear":                func(r *TaxRecord) *string { return &r.DataYear },
+	"DataMonth":               func(r *TaxRecord) *string { return &r.DataMonth },
+	"BuyerTaxId":              func(r *TaxRecord) *string { return &r.BuyerTaxId },
+	"BusinessNumber":          func(r *TaxRecord) *string { return &r.BusinessNumber },
+	"SellerTaxId":             func(r *TaxRecord) *string { return &r.SellerTaxId },
+	"InvoicePrefix":           func(r *TaxRecord) *string { return &r.InvoicePrefix },
+	"InvoiceStartNumber":      func(r *TaxRecord) *string { return &r.InvoiceStartNumber },
+	"TotalSheets":             func(r *TaxRecord) *string { return &r.TotalSheets },
+	"Blank1":                  func(r *TaxRecord) *string { return &r.Blank1 },
+	"Blank2":                  func(r *TaxRecord) *string { return &r.Blank2 },
+	"OtherVoucherNumber":      func(r *TaxRecord) *string { return &r.OtherVoucherNumber },
+	"UtilitySequenceNumber":   func(r *TaxRecord) *string { return &r.UtilitySequenceNumber },
+	"CustomsTaxPaymentNumber": func(r *TaxRecord) *string { return &r.CustomsTaxPaymentNumber },
+	"SalesAmount":             func(r *TaxRecord) *string { return &r.SalesAmount },
+	"TaxBase":                 func(r *TaxRecord) *string { return &r.TaxBase },
+	"TaxType":                 func(r *TaxRecord) *string { return &r.TaxType },
+	"TaxAmount":               func(r *TaxRecord) *string { return &r.TaxAmount },
+	"DeductionCode":           func(r *TaxRecord) *string { return &r.DeductionCode },
+	"Blank3":                  func(r *TaxRecord) *string { return &r.Blank3 },
+	"SpecialTaxRate":          func(r *TaxRecord) *string { return &r.SpecialTaxRate },
+	"AggregationMark":         func(r *TaxRecord) *string { return &r.AggregationMark },
+	"AllocationMark":          func(r *TaxRecord) *string { return &r.AllocationMark },
+	"CustomsClearanceMark":    func(r *TaxRecord) *string { return &r.CustomsClearanceMark },
+}
+
+// numericFieldLengths 數字欄位 9(n) 的長度
+var numericFieldLengths = map[string]int{
+	"DataYear":           3,
+	"DataMonth":          2,
+	"BusinessNumber":     8,
+	"InvoiceStartNumber": 8,
+	"TotalSheets":        4,
+	"SalesAmount":        12,
+	"TaxBase":            12,
+	"TaxAmount":          10,
+}
+
+// FieldLabel 取得欄位中文名稱（查無對應時回傳欄位名）
+func FieldLabel(field string) string {
+	if label, ok := fieldLabels[field]; ok {
+		return label
+	}
+	return field
+}
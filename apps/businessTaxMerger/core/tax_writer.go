@@ -0,0 +1,129 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// FormatRecord 將 TaxRecord 轉回媒體檔固定長度格式（ParseLine 的反向操作）
+// 回傳 81 位元組的規格位元組（Big5），不含換行
+// X(n) 欄位靠左補空白，9(n) 欄位靠右補零
+func FormatRecord(record *TaxRecord) ([]byte, error) {
+	data := bytes.Repeat([]byte{' '}, RecordLength)
+	errs := make([]string, 0)
+
+	putText := func(field string, start, length int, value string) {
+		if err := putField(data, start, length, value, false); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", FieldLabel(field), err))
+		}
+	}
+	putNumber := func(field string, start, length int, value string) {
+		if err := putField(data, start, length, value, true); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", FieldLabel(field), err))
+		}
+	}
+
+	// 基本資訊
+	putText("FormatCode", 0, 2, record.FormatCode)
+	putText("DeclarantTaxId", 2, 9, record.DeclarantTaxId)
+	putText("SequenceNumber", 11, 7, record.SequenceNumber)
+
+	// 資料所屬年月
+	putNumber("DataYear", 18, 3, record.DataYear)
+	putNumber("DataMonth", 21, 2, record.DataMonth)
+
+	// 位置 24-49 依資料類別決定欄位
+	switch record.Kind {
+	case KindInvoice:
+		if record.IsOutput() && record.IsBlank() {
+			putNumber("BusinessNumber", 23, 8, record.BusinessNumber)
+		} else {
+			putText("BuyerTaxId", 23, 8, record.BuyerTaxId)
+		}
+		putText("SellerTaxId", 31, 8, record.SellerTaxId)
+		putText("InvoicePrefix", 39, 2, record.InvoicePrefix)
+		putNumber("InvoiceStartNumber", 41, 8, record.InvoiceStartNumber)
+
+	case KindOtherVoucher:
+		putText("BuyerTaxId", 23, 8, record.BuyerTaxId)
+		putText("SellerTaxId", 31, 8, record.SellerTaxId)
+		putText("OtherVoucherNumber", 39, 10, record.OtherVoucherNumber)
+
+	case KindUtilityVoucher:
+		putText("BuyerTaxId", 23, 8, record.BuyerTaxId)
+		putText("SellerTaxId", 31, 8, record.SellerTaxId)
+		putText("UtilitySequenceNumber", 39, 10, record.UtilitySequenceNumber)
+
+	case KindSummary:
+		putNumber("BusinessNumber", 23, 8, record.BusinessNumber)
+		putNumber("TotalSheets", 31, 4, record.TotalSheets)
+		putText("Blank1", 35, 4, record.Blank1)
+		if record.InvoicePrefix != "" {
+			putText("InvoicePrefix", 39, 2, record.InvoicePrefix)
+			putNumber("InvoiceStartNumber", 41, 8, record.InvoiceStartNumber)
+		} else {
+			putText("OtherVoucherNumber", 39, 10, record.OtherVoucherNumber)
+		}
+
+	case KindCustoms:
+		putText("BuyerTaxId", 23, 8, record.BuyerTaxId)
+		putText("Blank2", 31, 4, record.Blank2)
+		putText("CustomsTaxPaymentNumber", 35, 14, record.CustomsTaxPaymentNumber)
+
+	default:
+		return nil, fmt.Errorf("未知的格式代號 '%s'，無法轉換", record.FormatCode)
+	}
+
+	// 金額
+	if record.Kind == KindCustoms {
+		putNumber("TaxBase", 49, 12, record.TaxBase)
+	} else {
+		putNumber("SalesAmount", 49, 12, record.SalesAmount)
+	}
+
+	putText("TaxType", 61, 1, record.TaxType)
+	putNumber("TaxAmount", 62, 10, record.TaxAmount)
+	putText("DeductionCode", 72, 1, record.DeductionCode)
+	putText("Blank3", 73, 5, record.Blank3)
+	putText("SpecialTaxRate", 78, 1, record.SpecialTaxRate)
+
+	// 位置 80：銷項為彙加註記，進項為分攤註記
+	if record.IsOutput() {
+		putText("AggregationMark", 79, 1, record.AggregationMark)
+	} else {
+		putText("AllocationMark", 79, 1, record.AllocationMark)
+	}
+
+	putText("CustomsClearanceMark", 80, 1, record.CustomsClearanceMark)
+
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("%s 第 %d 行轉換失敗: %s", record.SourceFileName, record.LineNumber, strings.Join(errs, "; "))
+	}
+
+	return data, nil
+}
+
+// putField 將欄位值寫入規格位元組的指定位置
+// numeric 為 true 時靠右補零，否則靠左補空白
+func putField(data []byte, start, length int, value string, numeric bool) error {
+	encoded := toSpecBytes(value)
+	if len(encoded) > length {
+		return fmt.Errorf("內容 '%s' 超過欄位長度 %d 位元組", value, length)
+	}
+
+	if numeric {
+		if value != "" && !isDigits(value) {
+			return fmt.Errorf("內容 '%s' 應為數字", value)
+		}
+		padding := length - len(encoded)
+		for i := 0; i < padding; i++ {
+			data[start+i] = '0'
+		}
+		copy(data[start+padding:], encoded)
+		return nil
+	}
+
+	copy(data[start:], encoded)
+	return nil
+}
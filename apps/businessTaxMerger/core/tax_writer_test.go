@@ -0,0 +1,184 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripCases 各資料類別的範例資料行（81 位元組）與解析後位置 24-31 及金額的預期值
+var roundTripCases = []struct {
+	name           string
+	line           string
+	kind           RecordKind
+	buyerTaxId     string
+	businessNumber string
+	amount         string
+}{
+	{
+		name:       "進項三聯式統一發票",
+		line:       "21" + "123456789" + "0000001" + "113" + "01" + "04595257" + "22099131" + "AB" + "12345678" + "000000010000" + "1" + "0000000500" + "1" + "     " + " " + " " + " ",
+		kind:       KindInvoice,
+		buyerTaxId: "04595257",
+		amount:     "000000010000",
+	},
+	{
+		name:       "進項載有稅額之其他憑證",
+		line:       "22" + "123456789" + "0000002" + "113" + "01" + "04595257" + "22099131" + "0123456789" + "000000001000" + "1" + "0000000050" + "1" + "     " + " " + " " + " ",
+		kind:       KindOtherVoucher,
+		buyerTaxId: "04595257",
+		amount:     "000000001000",
+	},
+	{
+		name:       "公用事業收據",
+		line:       "25" + "123456789" + "0000003" + "113" + "01" + "04595257" + "22099131" + "1234567890" + "000000002000" + "1" + "0000000100" + "1" + "     " + " " + " " + " ",
+		kind:       KindUtilityVoucher,
+		buyerTaxId: "04595257",
+		amount:     "000000002000",
+	},
+	{
+		name:           "彙總登錄",
+		line:           "26" + "123456789" + "0000004" + "113" + "01" + "12345699" + "0100" + "    " + "CD" + "12345600" + "000000100000" + "1" + "0000005000" + "1" + "     " + " " + " " + " ",
+		kind:           KindSummary,
+		businessNumber: "12345699",
+		amount:         "000000100000",
+	},
+	{
+		name:       "海關代徵營業稅繳納證",
+		line:       "28" + "123456789" + "0000005" + "113" + "01" + "04595257" + "    " + "AA123456789012" + "000000200000" + "1" + "0000010000" + "2" + "     " + " " + " " + "1",
+		kind:       KindCustoms,
+		buyerTaxId: "04595257",
+		amount:     "000000200000",
+	},
+	{
+		name:       "銷項三聯式統一發票（彙加註記）",
+		line:       "31" + "123456789" + "0000006" + "113" + "02" + "04595257" + "22099131" + "EF" + "87654321" + "000000030000" + "1" + "0000001500" + " " + "     " + " " + "A" + " ",
+		kind:       KindInvoice,
+		buyerTaxId: "04595257",
+		amount:     "000000030000",
+	},
+	{
+		name:           "銷項空白未使用發票",
+		line:           "31" + "123456789" + "0000007" + "113" + "02" + "00000050" + "22099131" + "EF" + "00000001" + "000000000000" + "D" + "0000000000" + " " + "     " + " " + " " + " ",
+		kind:           KindInvoice,
+		businessNumber: "00000050",
+		amount:         "000000000000",
+	},
+	{
+		name:   "銷項含全形字（Big5 每字 2 位元組）",
+		line:   "35" + "123456789" + "0000008" + "113" + "02" + "        " + "22099131" + "GH" + "00000001" + "000000000100" + "1" + "0000000005" + " " + "備註 " + " " + " " + " ",
+		kind:   KindInvoice,
+		amount: "000000000100",
+	},
+}
+
+func TestFormatRecordRoundTrip(t *testing.T) {
+	for _, tc := range roundTripCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if length := len(toSpecBytes(tc.line)); length != RecordLength {
+				t.Fatalf("範例長度 %d 位元組，應為 %d", length, RecordLength)
+			}
+			if err := checkRoundTrip(tc.line); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParseLineSharedFields(t *testing.T) {
+	for _, tc := range roundTripCases {
+		t.Run(tc.name, func(t *testing.T) {
+			record, err := ParseLine(tc.line, 1, "sample.txt")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if record.Kind != tc.kind {
+				t.Errorf("資料類別 = %s，應為 %s", record.Kind, tc.kind)
+			}
+			if record.BuyerTaxId != tc.buyerTaxId {
+				t.Errorf("買受人統一編號 = %q，應為 %q", record.BuyerTaxId, tc.buyerTaxId)
+			}
+			if record.BusinessNumber != tc.businessNumber {
+				t.Errorf("發票訖號 = %q，應為 %q", record.BusinessNumber, tc.businessNumber)
+			}
+			if record.Amount() != tc.amount {
+				t.Errorf("金額 = %q，應為 %q", record.Amount(), tc.amount)
+			}
+		})
+	}
+}
+
+// checkRoundTrip 檢查資料行解析後再轉回固定長度格式是否與原始位元組完全相同
+func checkRoundTrip(line string) error {
+	record, err := ParseLine(line, 1, "")
+	if err != nil {
+		return err
+	}
+
+	formatted, err := FormatRecord(record)
+	if err != nil {
+		return err
+	}
+
+	original := toSpecBytes(line)
+	if !bytes.Equal(formatted, original) {
+		return fmt.Errorf("轉換結果不一致\n  原始: %q\n  轉換: %q", fromSpecBytes(original), fromSpecBytes(formatted))
+	}
+	return nil
+}
+
+func TestCheckRoundTripRejectsMalformedLine(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+	}{
+		{name: "空白行", line: "   "},
+		{name: "長度不足", line: roundTripCases[0].line[:80]},
+		{name: "格式代號錯誤", line: "99" + roundTripCases[0].line[2:]},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := checkRoundTrip(tc.line); err == nil {
+				t.Fatal("應回傳錯誤")
+			}
+		})
+	}
+}
+
+// testLine 將測試資料轉為 81 位元組的媒體檔資料行（只需填寫用到的欄位，資料類別依格式代號與字軌判定）
+func testLine(t testing.TB, record TaxRecord) string {
+	t.Helper()
+	record.Kind = detectRecordKind(record.FormatCode, record.InvoicePrefix)
+	data, err := FormatRecord(&record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fromSpecBytes(data)
+}
+
+// testRecord 將測試資料轉為資料行後再解析，取得與讀取 TXT 相同的 TaxRecord
+func testRecord(t testing.TB, record TaxRecord) *TaxRecord {
+	t.Helper()
+	parsed, err := ParseLine(testLine(t, record), 1, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// writeTestTxt 將測試資料寫成 dir 下的 TXT 檔（CRLF 換行），回傳檔案路徑
+func writeTestTxt(t testing.TB, dir string, name string, records ...TaxRecord) string {
+	t.Helper()
+	lines := make([]string, len(records))
+	for i, record := range records {
+		lines[i] = testLine(t, record)
+	}
+	filePath := filepath.Join(dir, name)
+	if err := os.WriteFile(filePath, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return filePath
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// sequenceNumberLength 流水號長度
+const sequenceNumberLength = 7
+
+// MergeToTxt 將分配的檔案依序合併為單一媒體申報 TXT 檔（Big5、CRLF 換行）
+// renumber 為 true 時依申報營業人重新編列流水號，讓合併後的檔案可通過申報檢核
+// 結構錯誤的資料行不會寫入，列於回傳的驗證報告中；ctx 取消時中止並刪除寫到一半的檔案
+func MergeToTxt(ctx context.Context, allocation [][]*TxtFileInfo, outputFolder string, renumber bool, options ValidationOptions) (*ValidationReport, error) {
+	timestamp := time.Now().Format("20060102_150405")
+	fileName := fmt.Sprintf("營業人進銷項資料_合併_%s.txt", timestamp)
+	fullPath := filepath.Join(outputFolder, fileName)
+	report := &ValidationReport{}
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return report, fmt.Errorf("建立 TXT 檔案失敗: %v", err)
+	}
+
+	writer := bufio.NewWriter(file)
+	sequences := make(map[string]int)
+	recordCount := 0
+
+	writeErr := func() error {
+		for _, fileGroup := range allocation {
+			for _, fileInfo := range fileGroup {
+				fmt.Printf("正在合併 %s...\n", fileInfo.FileName)
+
+				err := fileInfo.EachRecord(func(record *TaxRecord) error {
+					if err := ctx.Err(); err != nil {
+						return err
+					}
+					report.Add(ValidateRecord(record, options)...)
+
+					if renumber {
+						sequences[record.DeclarantTaxId]++
+						record.SequenceNumber = fmt.Sprintf("%0*d", sequenceNumberLength, sequences[record.DeclarantTaxId])
+					}
+
+					if err := writeTxtRecord(writer, record); err != nil {
+						return err
+					}
+					recordCount++
+					return nil
+				})
+				if err != nil {
+					return fmt.Errorf("合併檔案 %s 失敗: %v", fileInfo.FileName, err)
+				}
+				report.AddParseErrors(fileInfo.ParseErrors...)
+			}
+		}
+		return writer.Flush()
+	}()
+
+	closeErr := file.Close()
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+	if writeErr != nil {
+		// 移除寫到一半的檔案
+		os.Remove(fullPath)
+		return report, fmt.Errorf("產生 TXT 檔案失敗: %v", writeErr)
+	}
+
+	fmt.Printf("✓ 已產出: %s（共 %d 筆資料）\n", fileName, recordCount)
+	if report.HasParseErrors() {
+		fmt.Printf("⚠ %d 個結構錯誤的資料行未寫入\n", len(report.ParseErrors))
+	}
+
+	return report, nil
+}
+
+// writeTxtRecord 寫入一筆固定長度資料（CRLF 換行）
+func writeTxtRecord(writer *bufio.Writer, record *TaxRecord) error {
+	data, err := FormatRecord(record)
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return err
+	}
+	_, err = writer.WriteString("\r\n")
+	return err
+}
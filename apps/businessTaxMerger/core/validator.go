@@ -0,0 +1,232 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// ValidationIssue 驗證問題
+type ValidationIssue struct {
+	// FileName 來源檔案名稱
+	FileName string
+
+	// LineNumber 行號
+	LineNumber int
+
+	// Field 欄位名稱（TaxRecord 欄位名）
+	Field string
+
+	// Value 欄位內容
+	Value string
+
+	// Reason 問題說明
+	Reason string
+}
+
+// ValidationReport 驗證報告
+type ValidationReport struct {
+	// Issues 資料內容驗證問題（資料仍會匯出）
+	Issues []ValidationIssue
+
+	// ParseErrors 結構錯誤（資料行未匯出）
+	ParseErrors []ParseError
+}
+
+// Add 加入驗證問題
+func (r *ValidationReport) Add(issues ...ValidationIssue) {
+	r.Issues = append(r.Issues, issues...)
+}
+
+// AddParseErrors 加入結構錯誤
+func (r *ValidationReport) AddParseErrors(errs ...ParseError) {
+	r.ParseErrors = append(r.ParseErrors, errs...)
+}
+
+// Merge 合併另一份驗證報告
+func (r *ValidationReport) Merge(other *ValidationReport) {
+	if other == nil {
+		return
+	}
+	r.Issues = append(r.Issues, other.Issues...)
+	r.ParseErrors = append(r.ParseErrors, other.ParseErrors...)
+}
+
+// HasIssues 是否有驗證問題
+func (r *ValidationReport) HasIssues() bool {
+	return len(r.Issues) > 0
+}
+
+// HasParseErrors 是否有結構錯誤
+func (r *ValidationReport) HasParseErrors() bool {
+	return len(r.ParseErrors) > 0
+}
+
+// 統一編號檢查碼權數
+var businessIdWeights = [8]int{1, 2, 1, 2, 1, 2, 4, 1}
+
+// ValidateBusinessId 檢查統一編號（8 碼）
+// 各位數乘上權數 1,2,1,2,1,2,4,1 後將乘積的十位數與個位數相加，總和能被 5 整除即為正確
+// （舊制為被 10 整除，新制放寬為被 5 整除，舊號碼在新制下仍然成立）
+// 第 7 碼為 7 時乘積為 28，2+8=10 可再取 1 或 0，總和或總和加 1 能被 5 整除皆為正確
+func ValidateBusinessId(id string) bool {
+	if len(id) != 8 || !isDigits(id) {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 8; i++ {
+		product := int(id[i]-'0') * businessIdWeights[i]
+		sum += product/10 + product%10
+	}
+
+	if sum%5 == 0 {
+		return true
+	}
+	// 第 7 碼為 7：乘積 28 的 2+8=10 再取 1+0=1，等同總和減 9，也可視為 0，等同總和減 10
+	if id[6] == '7' && (sum-9)%5 == 0 {
+		return true
+	}
+	return false
+}
+
+// ValidateDeclarantId 檢查申報營業人稅籍編號（9 碼數字）
+func ValidateDeclarantId(id string) bool {
+	return len(id) == 9 && isDigits(id)
+}
+
+// ValidationOptions 驗證選項
+type ValidationOptions struct {
+	// TaxTolerance 營業稅額與銷售金額 × 稅率之間容許的四捨五入差額（元，每張發票），0 表示須完全相符
+	TaxTolerance int64
+}
+
+// DefaultValidationOptions 預設驗證選項
+func DefaultValidationOptions() ValidationOptions {
+	return ValidationOptions{TaxTolerance: DefaultTaxTolerance}
+}
+
+// ValidateRecord 驗證單筆資料，回傳所有驗證問題
+func ValidateRecord(record *TaxRecord, options ValidationOptions) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+
+	addIssue := func(field, value, reason string) {
+		issues = append(issues, ValidationIssue{
+			FileName:   record.SourceFileName,
+			LineNumber: record.LineNumber,
+			Field:      field,
+			Value:      value,
+			Reason:     reason,
+		})
+	}
+
+	if !ValidateDeclarantId(record.DeclarantTaxId) {
+		addIssue("DeclarantTaxId", record.DeclarantTaxId, "稅籍編號應為 9 碼數字")
+	}
+
+	// 買受人、銷售人統一編號可能為空白（例如二聯式發票未載買受人），有填寫才檢查
+	if record.BuyerTaxId != "" && !ValidateBusinessId(record.BuyerTaxId) {
+		addIssue("BuyerTaxId", record.BuyerTaxId, "買受人統一編號檢查碼錯誤")
+	}
+	if record.SellerTaxId != "" && !ValidateBusinessId(record.SellerTaxId) {
+		addIssue("SellerTaxId", record.SellerTaxId, "銷售人統一編號檢查碼錯誤")
+	}
+
+	if record.IsVoided() || record.IsBlank() {
+		issues = append(issues, validateVoidedOrBlank(record)...)
+	} else {
+		issues = append(issues, validateTaxAmount(record, options.TaxTolerance)...)
+	}
+	if record.Kind == KindSummary && record.InvoicePrefix != "" {
+		issues = append(issues, validateSummaryRange(record)...)
+	}
+
+	return issues
+}
+
+// validateVoidedOrBlank 檢查作廢與空白未使用發票的欄位：僅限銷項統一發票、銷售金額與稅額為 0，
+// 空白未使用發票的訖號（BusinessNumber）不可小於起號
+func validateVoidedOrBlank(record *TaxRecord) []ValidationIssue {
+	issues := make([]ValidationIssue, 0)
+	addIssue := func(field, value, reason string) {
+		issues = append(issues, ValidationIssue{
+			FileName:   record.SourceFileName,
+			LineNumber: record.LineNumber,
+			Field:      field,
+			Value:      value,
+			Reason:     reason,
+		})
+	}
+
+	status := "作廢"
+	if record.IsBlank() {
+		status = "空白未使用"
+	}
+	if !outputInvoiceFormatCodes[record.FormatCode] || record.Kind != KindInvoice {
+		addIssue("TaxType", record.TaxType, status+"發票僅適用於銷項統一發票（格式代號 31、32、35、37）")
+		return issues
+	}
+	if parseAmountToInt(record.SalesAmount) != 0 {
+		addIssue("SalesAmount", record.SalesAmount, status+"發票銷售金額應為 0")
+	}
+	if parseAmountToInt(record.TaxAmount) != 0 {
+		addIssue("TaxAmount", record.TaxAmount, status+"發票營業稅額應為 0")
+	}
+	if record.IsBlank() && record.BusinessNumber < record.InvoiceStartNumber {
+		addIssue("BusinessNumber", record.BusinessNumber, "空白未使用發票訖號不可小於起號")
+	}
+	return issues
+}
+
+// ValidateFiles 逐筆驗證已分析的檔案（不匯出），回傳所有驗證問題（含跨檔重複發票與彙總登錄範圍）與結構錯誤
+// 逐筆驗證與跨資料檢查共用同一次讀取
+func ValidateFiles(ctx context.Context, fileInfoList []*TxtFileInfo, options ValidationOptions) (*ValidationReport, error) {
+	report := &ValidationReport{}
+	checks, err := checkFiles(ctx, fileInfoList, func(_ *TxtFileInfo, record *TaxRecord) {
+		report.Add(ValidateRecord(record, options)...)
+	})
+	if err != nil {
+		return report, err
+	}
+	for _, fileInfo := range fileInfoList {
+		report.AddParseErrors(fileInfo.ParseErrors...)
+	}
+
+	report.Add(checks.Duplicates.Issues()...)
+	report.Add(checks.SummaryRangeIssues()...)
+	return report, nil
+}
+
+// crossRecordChecks 分配前跨資料檢查（CheckFiles 或 FindDuplicates、CheckSummaryRanges）記錄在檔案上的單筆結果
+type crossRecordChecks struct {
+	// duplicate 所屬的重複發票（沒有重複時為 nil）
+	duplicate *DuplicateGroup
+
+	// issues 彙總登錄範圍的驗證問題
+	issues []ValidationIssue
+}
+
+// crossRecordChecks 指定行號的跨資料檢查結果
+func (info *TxtFileInfo) crossRecordChecks(lineNumber int) crossRecordChecks {
+	return crossRecordChecks{
+		duplicate: info.duplicateGroup(lineNumber),
+		issues:    info.recordRangeIssues(lineNumber),
+	}
+}
+
+// String 驗證問題描述
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s 第 %d 行 %s [%s]: %s", i.FileName, i.LineNumber, FieldLabel(i.Field), i.Value, i.Reason)
+}
+
+// isDigits 是否全為數字
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < '0' || value[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestValidateBusinessId(t *testing.T) {
+	// 註解中的總和為各位數乘上權數 1,2,1,2,1,2,4,1 後，乘積十位數與個位數相加的總和
+	cases := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "總和 40 被 10 整除", id: "04595257", want: true},
+		{name: "總和 30 被 10 整除", id: "22099131", want: true},
+		{name: "總和 35 只被 5 整除（新制）", id: "04595252", want: true},
+		{name: "總和 41", id: "04595258", want: false},
+		{name: "總和 38", id: "04595255", want: false},
+		{name: "第 7 碼為 7，總和 39 加 1 被 5 整除", id: "12345675", want: true},
+		{name: "第 7 碼為 7，總和 29 加 1 被 5 整除", id: "53212571", want: true},
+		{name: "第 7 碼為 7，總和 44 加 1 被 5 整除", id: "04595273", want: true},
+		{name: "第 7 碼為 7，總和 42 與加 1 皆不被 5 整除", id: "12345678", want: false},
+		{name: "第 7 碼不是 7 時不適用加 1（總和 39）", id: "04595256", want: false},
+		{name: "7 碼", id: "0459525", want: false},
+		{name: "9 碼", id: "045952570", want: false},
+		{name: "含英文字母", id: "0459525A", want: false},
+		{name: "含空白", id: "0459525 ", want: false},
+		{name: "空白", id: "", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ValidateBusinessId(tc.id); got != tc.want {
+				t.Errorf("ValidateBusinessId(%q) = %v，應為 %v", tc.id, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateDeclarantId(t *testing.T) {
+	cases := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{name: "9 碼數字", id: "123456789", want: true},
+		{name: "開頭為 0", id: "012345678", want: true},
+		{name: "8 碼", id: "12345678", want: false},
+		{name: "10 碼", id: "1234567890", want: false},
+		{name: "含英文字母", id: "12345678A", want: false},
+		{name: "開頭為空白", id: " 23456789", want: false},
+		{name: "空白", id: "", want: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := ValidateDeclarantId(tc.id); got != tc.want {
+				t.Errorf("ValidateDeclarantId(%q) = %v，應為 %v", tc.id, got, tc.want)
+			}
+		})
+	}
+}
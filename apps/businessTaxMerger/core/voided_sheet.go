@@ -0,0 +1,99 @@
+package core
+
+import (
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// voidedSheetName 作廢及空白發票工作表名稱
+const voidedSheetName = "作廢及空白發票"
+
+// voidedInvoice 作廢或空白未使用發票（空白未使用為一段號碼）
+type voidedInvoice struct {
+	DeclarantTaxId string
+	DataYear       string
+	DataMonth      string
+	FormatCode     string
+	Status         InvoiceStatus
+	InvoicePrefix  string
+	StartNumber    string
+	EndNumber      string
+	FileName       string
+	LineNumber     int
+}
+
+// newVoidedInvoice 由作廢或空白未使用發票資料建立（作廢為單張，起訖號相同）
+func newVoidedInvoice(record *TaxRecord) voidedInvoice {
+	invoice := voidedInvoice{
+		DeclarantTaxId: record.DeclarantTaxId,
+		DataYear:       record.DataYear,
+		DataMonth:      record.DataMonth,
+		FormatCode:     record.FormatCode,
+		Status:         InvoiceVoided,
+		InvoicePrefix:  record.InvoicePrefix,
+		StartNumber:    record.InvoiceStartNumber,
+		EndNumber:      record.InvoiceStartNumber,
+		FileName:       record.SourceFileName,
+		LineNumber:     record.LineNumber,
+	}
+	if record.IsBlank() {
+		invoice.Status = InvoiceBlank
+		invoice.EndNumber = record.BusinessNumber
+	}
+	return invoice
+}
+
+// count 張數（號碼無法解析或訖號小於起號時為 0）
+func (v voidedInvoice) count() int {
+	start, err := strconv.Atoi(v.StartNumber)
+	if err != nil {
+		return 0
+	}
+	end, err := strconv.Atoi(v.EndNumber)
+	if err != nil || end < start {
+		return 0
+	}
+	return end - start + 1
+}
+
+// writeVoidedSheet 寫入作廢及空白發票工作表：逐筆列出號碼範圍與來源，最後為作廢與空白未使用的張數合計
+func writeVoidedSheet(f *excelize.File, invoices []voidedInvoice) error {
+	w, err := newSheetWriter(f, voidedSheetName)
+	if err != nil {
+		return err
+	}
+
+	if err := w.writeRow(w.header,
+		FieldLabel("DeclarantTaxId"), FieldLabel("DataYear"), FieldLabel("DataMonth"), FieldLabel("FormatCode"), "類別",
+		FieldLabel("InvoicePrefix"), "起號", "訖號", "張數", FieldLabel("SourceFileName"), FieldLabel("LineNumber"),
+	); err != nil {
+		return err
+	}
+
+	voidedCount, blankCount := 0, 0
+	for _, invoice := range invoices {
+		count := invoice.count()
+		if invoice.Status == InvoiceBlank {
+			blankCount += count
+		} else {
+			voidedCount += count
+		}
+		if err := w.writeRow(w.number,
+			invoice.DeclarantTaxId, invoice.DataYear, invoice.DataMonth, invoice.FormatCode, invoice.Status.String(),
+			invoice.InvoicePrefix, invoice.StartNumber, invoice.EndNumber, count, invoice.FileName, invoice.LineNumber,
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writeRow(w.total, "作廢合計", "", "", "", "", "", "", "", voidedCount); err != nil {
+		return err
+	}
+	if err := w.writeRow(w.total, "空白未使用合計", "", "", "", "", "", "", "", blankCount); err != nil {
+		return err
+	}
+
+	// 設定欄寬
+	return w.setColWidths(18, 12, 12, 10, 12, 10, 12, 12, 10, 30, 8)
+}
@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// DefaultWorkers 預設的並行工作數（CPU 核心數）
+// 分析與匯出時同時開啟的檔案數不會超過此數量，避免大量檔案時超出系統開檔上限
+func DefaultWorkers() int {
+	return runtime.NumCPU()
+}
+
+// runWorkers 以固定數量的 worker 並行處理 0 ~ count-1 的工作
+// workers <= 0 時使用 DefaultWorkers；任一工作回傳錯誤或 ctx 取消時，不再派發新工作並回傳第一個錯誤
+func runWorkers(ctx context.Context, workers int, count int, task func(ctx context.Context, index int) error) error {
+	if workers <= 0 {
+		workers = DefaultWorkers()
+	}
+	if workers > count {
+		workers = count
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				if err := task(ctx, index); err != nil {
+					once.Do(func() {
+						firstErr = err
+						cancel()
+					})
+				}
+			}
+		}()
+	}
+
+	// 派發工作，取消後停止派發
+dispatch:
+	for i := 0; i < count; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(indexes)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,26 @@
+// Package benchdata 產生效能測試用的媒體申報 TXT 資料，供 core 的 benchmark 與 testcase 工具共用
+package benchdata
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+)
+
+// WriteFile 產生效能測試用的 TXT 檔案，共 count 筆銷項三聯式統一發票（流水號與發票號碼遞增）
+func WriteFile(filePath string, count int) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for i := 0; i < count; i++ {
+		line := fmt.Sprintf("31123456789%07d11301%s%sEF%08d%012d1%010d%s", (i%9999999)+1, "04595257", "22099131", i, 10000, 500, "         ")
+		if _, err := writer.WriteString(line + "\r\n"); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
@@ -1,13 +1,11 @@
 package main
 
 import (
-	"fmt"
-	"time"
+	"os"
+
+	"accountingTools/apps/testcase/suite"
 )
 
 func main() {
-	fmt.Println("====================================")
-	fmt.Println("========== TestCase Tool ==========")
-	fmt.Println("====================================")
-	fmt.Printf("\n�LB�: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	os.Exit(suite.Run(os.Args[1:]))
 }
@@ -0,0 +1,121 @@
+package suite
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync/atomic"
+	"time"
+
+	"accountingTools/apps/businessTaxMerger/core"
+	"accountingTools/apps/internal/benchdata"
+)
+
+// Run 執行 Excel 匯出效能測試，回傳結束代碼
+// 媒體檔格式往返檢查已移至 core 的 go test
+func Run(args []string) int {
+	flags := flag.NewFlagSet("testcase", flag.ContinueOnError)
+	benchRows := flags.Int("bench-rows", 100000, "Excel 匯出效能測試的資料筆數")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "用法: testcase [-bench-rows <筆數>]")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+	if *benchRows <= 0 {
+		fmt.Fprintln(flags.Output(), "-bench-rows 必須大於 0")
+		return 2
+	}
+
+	fmt.Println("====================================")
+	fmt.Println("========== TestCase Tool ==========")
+	fmt.Println("====================================")
+	fmt.Printf("\n�LB�: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+
+	if err := benchmarkExport(*benchRows); err != nil {
+		fmt.Printf("效能測試失敗: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// benchmarkExport Excel 匯出效能測試：產生指定筆數的資料後匯出，顯示耗時與記憶體峰值
+func benchmarkExport(rows int) error {
+	tempDir, err := os.MkdirTemp("", "accountingTools_bench_")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+
+	// 產生測試資料（每 100,000 筆一個檔案）
+	const rowsPerFile = 100000
+	txtFiles := make([]string, 0)
+	for written := 0; written < rows; written += rowsPerFile {
+		filePath := filepath.Join(tempDir, fmt.Sprintf("bench_%03d.txt", len(txtFiles)+1))
+		count := rowsPerFile
+		if rows-written < count {
+			count = rows - written
+		}
+		if err := benchdata.WriteFile(filePath, count); err != nil {
+			return err
+		}
+		txtFiles = append(txtFiles, filePath)
+	}
+
+	fmt.Printf("\nExcel 匯出效能測試：%d 筆資料，%d 個檔案\n", rows, len(txtFiles))
+
+	// 每 50ms 取樣一次記憶體用量，記錄峰值
+	var peakHeap uint64
+	done := make(chan struct{})
+	go func() {
+		var stats runtime.MemStats
+		ticker := time.NewTicker(50 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			runtime.ReadMemStats(&stats)
+			if stats.HeapInuse > atomic.LoadUint64(&peakHeap) {
+				atomic.StoreUint64(&peakHeap, stats.HeapInuse)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	start := time.Now()
+	fileInfoList, err := core.AnalyzeFiles(context.Background(), txtFiles, 0)
+	if err != nil {
+		return err
+	}
+	defer core.ReleaseFiles(fileInfoList)
+	// 超過單一工作表上限時分到多個 Excel
+	excelCount := (rows + core.MaxExcelDataRows - 1) / core.MaxExcelDataRows
+	allocation, err := core.ValidateAndAllocateFiles(context.Background(), fileInfoList, core.MaxExcelDataRows, excelCount, core.StrategyNextFit, false)
+	if err != nil {
+		return err
+	}
+	if _, err := core.ExportToExcel(context.Background(), allocation, tempDir, core.MaxExcelDataRows, 0, nil, core.DefaultValidationOptions(), nil); err != nil {
+		return err
+	}
+	elapsed := time.Since(start)
+	close(done)
+
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	fmt.Println()
+	fmt.Printf("耗時: %v\n", elapsed.Round(time.Millisecond))
+	fmt.Printf("Heap 峰值: %.1f MB\n", float64(atomic.LoadUint64(&peakHeap))/1024/1024)
+	fmt.Printf("累計配置: %.1f MB\n", float64(stats.TotalAlloc)/1024/1024)
+	return nil
+}
@@ -9,7 +9,7 @@ echo "========================================="
 case "$APP_NAME" in
   "businessTaxMerger")
     echo "📦 編譯目標: BusinessTaxMerger"
-    GOOS=windows GOARCH=amd64 go build -o /app/BusinessTaxMerger.exe /app/apps/businessTaxMerger/main.go
+    GOOS=windows GOARCH=amd64 go build -o /app/BusinessTaxMerger.exe /app/apps/businessTaxMerger
     if [ $? -eq 0 ]; then
       echo "✅ 編譯成功: BusinessTaxMerger.exe"
       ls -lh /app/BusinessTaxMerger.exe
@@ -21,7 +21,7 @@ case "$APP_NAME" in
 
   "testcase")
     echo "📦 編譯目標: TestCase"
-    GOOS=windows GOARCH=amd64 go build -o /app/TestCase.exe /app/apps/testcase/main.go
+    GOOS=windows GOARCH=amd64 go build -o /app/TestCase.exe /app/apps/testcase
     if [ $? -eq 0 ]; then
       echo "✅ 編譯成功: TestCase.exe"
       ls -lh /app/TestCase.exe
@@ -31,14 +31,29 @@ case "$APP_NAME" in
     fi
     ;;
 
+  "accountingTools")
+    echo "📦 編譯目標: AccountingTools（整合所有工具的單一執行檔）"
+    GOOS=windows GOARCH=amd64 go build -o /app/AccountingTools.exe /app
+    if [ $? -eq 0 ]; then
+      echo "✅ 編譯成功: AccountingTools.exe"
+      ls -lh /app/AccountingTools.exe
+    else
+      echo "❌ 編譯失敗"
+      exit 1
+    fi
+    ;;
+
   "all")
     echo "📦 編譯目標: 全部"
     echo ""
+    echo "正在編譯 AccountingTools..."
+    GOOS=windows GOARCH=amd64 go build -o /app/AccountingTools.exe /app
+
     echo "正在編譯 BusinessTaxMerger..."
-    GOOS=windows GOARCH=amd64 go build -o /app/BusinessTaxMerger.exe /app/apps/businessTaxMerger/main.go
+    GOOS=windows GOARCH=amd64 go build -o /app/BusinessTaxMerger.exe /app/apps/businessTaxMerger
 
     echo "正在編譯 TestCase..."
-    GOOS=windows GOARCH=amd64 go build -o /app/TestCase.exe /app/apps/testcase/main.go
+    GOOS=windows GOARCH=amd64 go build -o /app/TestCase.exe /app/apps/testcase
 
     echo ""
     echo "✅ 全部編譯完成:"
@@ -51,6 +66,7 @@ case "$APP_NAME" in
     echo "可用選項:"
     echo "  - businessTaxMerger  (預設)"
     echo "  - testcase"
+    echo "  - accountingTools    (整合入口)"
     echo "  - all               (編譯全部)"
     echo ""
     echo "使用方式:"
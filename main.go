@@ -1,11 +1,207 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"accountingTools/apps/businessTaxMerger/cli"
+	"accountingTools/apps/testcase/suite"
 )
 
+// version 版本（可於編譯時以 -ldflags "-X main.version=..." 指定）
+var version = "2.0.0"
+
+// configFileName 預設設定檔名稱（依序尋找目前目錄與執行檔所在目錄）
+const configFileName = "accountingTools.json"
+
+// command 子指令
+type command struct {
+	name        string
+	summary     string
+	run         func(args []string) int
+	interactive func() // 沒有命令列參數時改為互動模式（不套用設定檔）
+}
+
+// commands 所有子指令（依說明順序）
+var commands = []command{
+	{"merge", "合併 TXT 並匯出 Excel、CSV、JSON Lines 或申報 TXT（不加參數時進入互動模式）", cli.Merge, cli.Interactive},
+	{"validate", "分析並驗證 TXT，有錯誤時結束代碼為 1", cli.Validate, nil},
+	{"convert", "將 Excel 轉回媒體申報 TXT", cli.Convert, nil},
+	{"report", "計算 401 申報書試算並匯出 Excel 與 JSON", cli.Report, nil},
+	{"testcase", "Excel 匯出效能測試", suite.Run, nil},
+}
+
 func main() {
-	// 設定終端機為 UTF-8 編碼 (Windows)
-	fmt.Println("\033[?25h") // 顯示游標
-	// 保留未來可整合成一個入口
+	os.Exit(run(os.Args[1:]))
+}
+
+// run 解析共用參數後執行子指令，回傳結束代碼
+// 用法：accountingTools [-config <設定檔>] <子指令> [參數]
+func run(args []string) int {
+	configPath := ""
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch arg := args[0]; {
+		case arg == "-h" || arg == "-help" || arg == "--help":
+			printHelp()
+			return 0
+		case arg == "-version" || arg == "--version":
+			printVersion()
+			return 0
+		case arg == "-config" || arg == "--config":
+			if len(args) < 2 {
+				fmt.Fprintln(os.Stderr, "參數錯誤: -config 需要指定設定檔路徑")
+				return 2
+			}
+			configPath = args[1]
+			args = args[1:]
+		case strings.HasPrefix(arg, "-config=") || strings.HasPrefix(arg, "--config="):
+			configPath = arg[strings.Index(arg, "=")+1:]
+		default:
+			fmt.Fprintf(os.Stderr, "參數錯誤: 無法辨識的參數 %s\n", arg)
+			return 2
+		}
+		args = args[1:]
+	}
+
+	if len(args) == 0 || args[0] == "help" {
+		if len(args) > 1 {
+			// help <子指令> 顯示該子指令的參數
+			return runCommand(args[1], []string{"-h"}, nil)
+		}
+		printHelp()
+		return 0
+	}
+	if args[0] == "version" {
+		printVersion()
+		return 0
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	return runCommand(args[0], args[1:], config)
+}
+
+// runCommand 執行子指令，設定檔中該子指令的預設參數放在命令列參數之前（命令列優先）
+// 命令列已指定的參數不使用設定檔的值，避免可重複指定的參數（例如 -input）把兩者合併
+func runCommand(name string, args []string, config map[string]map[string]interface{}) int {
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if len(args) == 0 && cmd.interactive != nil {
+			// 顯示游標（互動選單期間終端機可能隱藏游標）
+			fmt.Print("\033[?25h")
+			cmd.interactive()
+			return 0
+		}
+		cli.CommandName = "accountingTools " + name
+		return cmd.run(append(configArgs(config[name], args), args...))
+	}
+	fmt.Fprintf(os.Stderr, "未知的子指令 %q，請執行 accountingTools help 查看可用的子指令\n", name)
+	return 2
+}
+
+// loadConfig 讀取設定檔：{"子指令": {"參數名稱": 值}}
+// 未指定路徑時依序尋找目前目錄與執行檔所在目錄的 accountingTools.json，都沒有則不使用設定檔
+func loadConfig(path string) (map[string]map[string]interface{}, error) {
+	if path == "" {
+		candidates := []string{configFileName}
+		if executable, err := os.Executable(); err == nil {
+			candidates = append(candidates, filepath.Join(filepath.Dir(executable), configFileName))
+		}
+		for _, candidate := range candidates {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+		if path == "" {
+			return nil, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("讀取設定檔失敗: %v", err)
+	}
+	// 數字保留原始文字，避免大數字轉成科學記號（例如 1048576 → 1.048576e+06）
+	config := make(map[string]map[string]interface{})
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("設定檔 %s 格式錯誤: %v", path, err)
+	}
+	return config, nil
+}
+
+// configArgs 將設定檔中的參數轉為命令列參數（依參數名稱排序；陣列值展開為重複的參數）
+// 略過命令列 commandLine 已指定的參數
+func configArgs(values map[string]interface{}, commandLine []string) []string {
+	given := flagNames(commandLine)
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if !given[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	args := make([]string, 0, len(values))
+	for _, name := range names {
+		items, ok := values[name].([]interface{})
+		if !ok {
+			items = []interface{}{values[name]}
+		}
+		for _, item := range items {
+			args = append(args, fmt.Sprintf("-%s=%v", name, item))
+		}
+	}
+	return args
+}
+
+// flagNames 命令列中指定的參數名稱（-name、--name、-name=值；-- 之後不再是參數）
+func flagNames(args []string) map[string]bool {
+	names := make(map[string]bool)
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+		name, _, _ = strings.Cut(name, "=")
+		names[name] = true
+	}
+	return names
+}
+
+// printHelp 顯示共用說明
+func printHelp() {
+	fmt.Printf("accountingTools %s\n\n", version)
+	fmt.Println("用法: accountingTools [-config <設定檔>] <子指令> [參數]")
+	fmt.Println()
+	fmt.Println("子指令：")
+	for _, cmd := range commands {
+		fmt.Printf("  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Println()
+	fmt.Println("  help <子指令>  顯示子指令的參數")
+	fmt.Println("  version       顯示版本")
+	fmt.Println()
+	fmt.Printf("設定檔（預設為目前目錄或執行檔所在目錄的 %s）可指定各子指令的預設參數，例如：\n", configFileName)
+	fmt.Println(`  {"merge": {"max-rows": 500000, "format": "csv"}, "report": {"previous-credit": ["123456789=1000"]}}`)
+}
+
+// printVersion 顯示版本
+func printVersion() {
+	fmt.Printf("accountingTools %s\n", version)
 }